@@ -0,0 +1,183 @@
+package map2struct
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// IPs and networks are also unmarshaled to map keys and set elements of netip.Addr and
+// netip.Prefix, since net.IP and net.IPNet are not comparable.
+func init() {
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(net.IP{}), convertIP))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(net.IPNet{}), convertIPNet))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(url.URL{}), convertURL))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(regexp.Regexp{}), convertRegexp))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(template.Template{}), convertTemplate))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(time.Location{}), convertLocation))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(mail.Address{}), convertMailAddress))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(netip.Addr{}), convertNetipAddr))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(netip.Prefix{}), convertNetipPrefix))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(netip.AddrPort{}), convertNetipAddrPort))
+	RegisterConverter(NewGeneralConverter(reflect.TypeOf(os.FileMode(0)), convertFileMode))
+}
+
+func convertIP(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip: %s", text)
+	}
+	return ip, nil
+}
+
+// convertIPNet converts CIDR text to net.IPNet, the address is kept like netip.Prefix,
+// so "10.0.0.5/8" is 10.0.0.5 with the mask of 10.0.0.0/8.
+func convertIPNet(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	ip, ipNet, err := net.ParseCIDR(text)
+	if err != nil {
+		return nil, err
+	}
+	if ip4 := ip.To4(); ip4 != nil && len(ipNet.IP) == net.IPv4len {
+		ip = ip4
+	}
+	return &net.IPNet{IP: ip, Mask: ipNet.Mask}, nil
+}
+
+func convertURL(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(text)
+}
+
+func convertRegexp(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(text)
+}
+
+func convertTemplate(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return template.New("").Parse(text)
+}
+
+func convertLocation(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(text)
+}
+
+func convertMailAddress(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return mail.ParseAddress(text)
+}
+
+func convertNetipAddr(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return netip.ParseAddr(text)
+}
+
+func convertNetipPrefix(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return netip.ParsePrefix(text)
+}
+
+func convertNetipAddrPort(src interface{}) (interface{}, error) {
+	text, err := textOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return netip.ParseAddrPort(text)
+}
+
+// convertFileMode converts integers, integral floats, text of integers like "0644" or
+// "420" and permission text like "-rw-r--r--" to os.FileMode. Text is octal only with
+// a leading "0" like other integers.
+func convertFileMode(src interface{}) (interface{}, error) {
+	value := reflect.ValueOf(src)
+	kind := value.Kind()
+	switch {
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		return os.FileMode(value.Int()), nil
+	case kind >= reflect.Uint && kind <= reflect.Uint64:
+		return os.FileMode(value.Uint()), nil
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		if mode := value.Float(); mode >= 0 && mode <= math.MaxUint32 && mode == math.Trunc(mode) {
+			return os.FileMode(mode), nil
+		}
+		return nil, fmt.Errorf("invalid file mode: %v", src)
+	case kind != reflect.String:
+		return nil, fmt.Errorf("expect int/float/string but found %T", src)
+	}
+	text := value.String()
+	if len(text) == 10 && strings.Trim(text, "-drwx") == "" {
+		var mode os.FileMode
+		if text[0] == 'd' {
+			mode |= os.ModeDir
+		} else if text[0] != '-' {
+			return nil, fmt.Errorf("invalid file mode: %s", text)
+		}
+		for i, c := range text[1:] {
+			if c != '-' && c != rune("rwx"[i%3]) {
+				return nil, fmt.Errorf("invalid file mode: %s", text)
+			} else if c != '-' {
+				mode |= 1 << uint(8-i)
+			}
+		}
+		return mode, nil
+	}
+	mode, err := parseUintText(text)
+	if err != nil || mode > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid file mode: %s", text)
+	}
+	return os.FileMode(mode), nil
+}
+
+func textOf(src interface{}) (string, error) {
+	switch value := src.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	case encoding.TextMarshaler:
+		// values of other types like net.IP for netip.Addr
+		text, err := value.MarshalText()
+		return string(text), err
+	}
+	return "", fmt.Errorf("expect string/[]byte but found %T", src)
+}
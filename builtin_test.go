@@ -0,0 +1,180 @@
+package map2struct
+
+import (
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestBuiltinConverters(t *testing.T) {
+	type TestStruct struct {
+		IP       net.IP
+		IPNet    *net.IPNet
+		URL      *url.URL
+		Regexp   *regexp.Regexp
+		Template *template.Template
+		Location *time.Location
+		Address  *mail.Address
+		Addr     netip.Addr
+		Prefix   netip.Prefix
+		AddrPort netip.AddrPort
+		Mode     os.FileMode
+		Modes    []os.FileMode
+		Hosts    map[netip.Addr]string
+		Allowed  map[netip.Prefix]bool
+	}
+	src := map[string]interface{}{
+		"IP":       "192.168.0.1",
+		"IPNet":    "10.0.0.5/8",
+		"URL":      "https://example.com/path?q=1",
+		"Regexp":   "^a+$",
+		"Template": "hello {{.}}",
+		"Location": "UTC",
+		"Address":  "Foo <foo@example.com>",
+		"Addr":     "::1",
+		"Prefix":   "192.168.0.0/16",
+		"AddrPort": "127.0.0.1:80",
+		"Mode":     "0644",
+		"Modes":    []interface{}{"-rwxr-x---", 0600, "drwxr-xr-x"},
+		"Hosts": map[string]interface{}{
+			"127.0.0.1": "localhost",
+		},
+		"Allowed": []string{"10.0.0.0/8"},
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if !a.IP.Equal(net.IPv4(192, 168, 0, 1)) {
+		t.Error("unexpected ip:", a.IP)
+		return
+	}
+	if a.IPNet.String() != "10.0.0.5/8" || !a.IPNet.Contains(net.IPv4(10, 1, 2, 3)) {
+		t.Error("unexpected ip net:", a.IPNet)
+		return
+	}
+	if a.URL.Host != "example.com" || a.URL.Query().Get("q") != "1" {
+		t.Error("unexpected url:", a.URL)
+		return
+	}
+	if !a.Regexp.MatchString("aaa") || a.Regexp.MatchString("b") {
+		t.Error("unexpected regexp:", a.Regexp)
+		return
+	}
+	var output strings.Builder
+	if err := a.Template.Execute(&output, "world"); err != nil || output.String() != "hello world" {
+		t.Error("unexpected template:", output.String(), err)
+		return
+	}
+	if a.Location.String() != "UTC" {
+		t.Error("unexpected location:", a.Location)
+		return
+	}
+	if a.Address.Name != "Foo" || a.Address.Address != "foo@example.com" {
+		t.Error("unexpected address:", a.Address)
+		return
+	}
+	if a.Addr != netip.IPv6Loopback() {
+		t.Error("unexpected addr:", a.Addr)
+		return
+	}
+	if a.Prefix != netip.MustParsePrefix("192.168.0.0/16") {
+		t.Error("unexpected prefix:", a.Prefix)
+		return
+	}
+	if a.AddrPort != netip.MustParseAddrPort("127.0.0.1:80") {
+		t.Error("unexpected addr port:", a.AddrPort)
+		return
+	}
+	if a.Mode != 0644 {
+		t.Error("unexpected file mode:", a.Mode)
+		return
+	}
+	if len(a.Modes) != 3 || a.Modes[0] != 0750 || a.Modes[1] != 0600 || a.Modes[2] != os.ModeDir|0755 {
+		t.Error("unexpected file modes:", a.Modes)
+		return
+	}
+	if a.Hosts[netip.MustParseAddr("127.0.0.1")] != "localhost" {
+		t.Error("unexpected hosts:", a.Hosts)
+		return
+	}
+	if !a.Allowed[netip.MustParsePrefix("10.0.0.0/8")] {
+		t.Error("unexpected allowed:", a.Allowed)
+		return
+	}
+}
+
+func TestBuiltinIPKeys(t *testing.T) {
+	var hosts map[netip.Addr]string
+	if err := Unmarshal(&hosts, map[interface{}]interface{}{netip.MustParseAddr("10.0.0.1"): "a", "::1": "b"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(hosts) != 2 || hosts[netip.MustParseAddr("10.0.0.1")] != "a" || hosts[netip.IPv6Loopback()] != "b" {
+		t.Error("unexpected hosts:", hosts)
+		return
+	}
+	var allowed map[netip.Addr]bool
+	if err := Unmarshal(&allowed, []interface{}{net.ParseIP("192.168.0.1"), "10.0.0.1"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(allowed) != 2 || !allowed[netip.MustParseAddr("192.168.0.1")] {
+		t.Error("unexpected allowed:", allowed)
+		return
+	}
+}
+
+func TestBuiltinConvertersFail(t *testing.T) {
+	var ip net.IP
+	if err := Unmarshal(&ip, "abc"); err == nil {
+		t.Error("unexpected unmarshal success:", ip)
+		return
+	}
+	if err := Unmarshal(&ip, 1); err == nil {
+		t.Error("unexpected unmarshal success:", ip)
+		return
+	}
+	var re *regexp.Regexp
+	if err := Unmarshal(&re, "("); err == nil {
+		t.Error("unexpected unmarshal success:", re)
+		return
+	}
+	var mode os.FileMode
+	if err := Unmarshal(&mode, "-rwxrwxrwa"); err == nil {
+		t.Error("unexpected unmarshal success:", mode)
+		return
+	}
+	if err := Unmarshal(&mode, "xrwxrwxrwx"); err == nil {
+		t.Error("unexpected unmarshal success:", mode)
+		return
+	}
+	if err := Unmarshal(&mode, "0999"); err == nil {
+		t.Error("unexpected unmarshal success:", mode)
+		return
+	}
+	if err := Unmarshal(&mode, 420.5); err == nil {
+		t.Error("unexpected unmarshal success:", mode)
+		return
+	}
+	// decimal text and floats of encoding/json are decimal modes
+	if err := Unmarshal(&mode, "420"); err != nil || mode != 0644 {
+		t.Error("unexpected unmarshal result:", mode, err)
+		return
+	}
+	var config struct{ Mode os.FileMode }
+	if err := Unmarshal(&config, map[string]interface{}{"Mode": 420.0}); err != nil || config.Mode != 0644 {
+		t.Error("unexpected unmarshal result:", config.Mode, err)
+		return
+	}
+	if err := Unmarshal(&mode, true); err == nil {
+		t.Error("unexpected unmarshal success:", mode)
+		return
+	}
+}
@@ -0,0 +1,20 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Convert converts a struct to a struct of different type, like DTO to domain model.
+// Fields are matched by name, and converted with the same rules of Unmarshal.
+func Convert(dest, src interface{}) error {
+	return defaultDecoder.Convert(dest, src)
+}
+
+// Convert converts a struct to a struct of different type with the options of the decoder.
+func (decoder *Decoder) Convert(dest, src interface{}) error {
+	if value := reflect.Indirect(reflect.ValueOf(src)); value.Kind() != reflect.Struct {
+		return fmt.Errorf("invalid source: expect struct but found %T", src)
+	}
+	return decoder.Unmarshal(dest, src)
+}
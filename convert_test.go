@@ -0,0 +1,86 @@
+package map2struct
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type addressDTO struct {
+	City string
+}
+
+type userDTO struct {
+	Name      string
+	Level     string
+	IP        string
+	CreatedAt string
+	Address   *addressDTO
+	Friends   []addressDTO
+	Internal  int
+}
+
+type address struct {
+	City string
+}
+
+type user struct {
+	Name      string
+	Level     level
+	IP        net.IP
+	CreatedAt time.Time
+	Address   address
+	Friends   []*address
+}
+
+func TestConvert(t *testing.T) {
+	levelType := reflect.TypeOf(levelDebug)
+	defer delete(enums, levelType)
+	RegisterEnum(levelType, map[string]interface{}{
+		"debug": levelDebug,
+		"info":  levelInfo,
+	})
+	dto := userDTO{
+		Name:      "foo",
+		Level:     "info",
+		IP:        "127.0.0.1",
+		CreatedAt: "2017-03-04T05:06:07Z",
+		Address:   &addressDTO{City: "beijing"},
+		Friends:   []addressDTO{{City: "shanghai"}},
+		Internal:  1,
+	}
+	var u user
+	if err := Convert(&u, &dto); err != nil {
+		t.Error("convert fail:", err.Error())
+		return
+	}
+	if u.Name != "foo" || u.Level != levelInfo || !u.IP.Equal(net.IPv4(127, 0, 0, 1)) ||
+		!u.CreatedAt.Equal(time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)) || u.Address.City != "beijing" ||
+		len(u.Friends) != 1 || u.Friends[0].City != "shanghai" {
+		t.Error("unexpected convert result:", u)
+		return
+	}
+	// reverse
+	var back userDTO
+	if err := Convert(&back, u); err != nil {
+		t.Error("convert fail:", err.Error())
+		return
+	}
+	if back.Name != "foo" || back.IP != "127.0.0.1" || back.CreatedAt != "2017-03-04T05:06:07Z" ||
+		back.Address == nil || back.Address.City != "beijing" || len(back.Friends) != 1 || back.Friends[0].City != "shanghai" {
+		t.Error("unexpected convert result:", back)
+		return
+	}
+	// invalid source
+	if err := Convert(&u, map[string]interface{}{}); err == nil {
+		t.Error("unexpected convert success:", u)
+		return
+	}
+	// conversion fail
+	dto.IP = "abc"
+	if err := Convert(&u, dto); err == nil {
+		t.Error("unexpected convert success:", u)
+		return
+	}
+}
@@ -0,0 +1,85 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Converter represents a converter used in unmarshaling values of a specified type.
+// Converters take precedence over encoding.TextUnmarshaler.
+type Converter interface {
+	// GetInstanceType returns the type of the instance converted by this converter.
+	// The instance type is used to index the converter.
+	GetInstanceType() reflect.Type
+
+	// Convert returns the instance converted from the source value.
+	// The instance can be a value of the instance type or a pointer to it.
+	Convert(interface{}) (interface{}, error)
+}
+
+var (
+	converters = make(map[reflect.Type]Converter)
+)
+
+// RegisterConverter register converters.
+// A converter replaces the previous one registered for the same type.
+func RegisterConverter(converter Converter) {
+	converters[converter.GetInstanceType()] = converter
+}
+
+// LookupConverter returns the converter registered for the type, or nil if not registered.
+func LookupConverter(typ reflect.Type) Converter {
+	return converters[typ]
+}
+
+// GeneralConverter provides a converter with a convert function.
+type GeneralConverter struct {
+	instanceType reflect.Type
+	convert      func(interface{}) (interface{}, error)
+}
+
+// NewGeneralConverter creates a GeneralConverter instance.
+func NewGeneralConverter(instanceType reflect.Type, convert func(interface{}) (interface{}, error)) *GeneralConverter {
+	return &GeneralConverter{
+		instanceType: instanceType,
+		convert:      convert,
+	}
+}
+
+// GetInstanceType returns the instance type.
+func (converter *GeneralConverter) GetInstanceType() reflect.Type {
+	return converter.instanceType
+}
+
+// Convert converts the source value with the convert function.
+func (converter *GeneralConverter) Convert(src interface{}) (interface{}, error) {
+	return converter.convert(src)
+}
+
+func (decoder *Decoder) unmarshalConverter(converter Converter, dest, src reflect.Value) error {
+	// values of the instance type are assigned directly
+	if src.IsValid() && src.Type() == dest.Type() {
+		dest.Set(src)
+		return nil
+	} else if src.Kind() == reflect.Ptr && src.Type().Elem() == dest.Type() && !src.IsNil() {
+		dest.Set(src.Elem())
+		return nil
+	}
+	var srcValue interface{}
+	if src.IsValid() {
+		srcValue = src.Interface()
+	}
+	instance, err := converter.Convert(srcValue)
+	if err != nil {
+		return err
+	}
+	value := reflect.ValueOf(instance)
+	if value.IsValid() && value.Type() == dest.Type() {
+		dest.Set(value)
+	} else if value.Kind() == reflect.Ptr && value.Type().Elem() == dest.Type() && !value.IsNil() {
+		dest.Set(value.Elem())
+	} else {
+		return fmt.Errorf("converter of %s returns %T", dest.Type(), instance)
+	}
+	return nil
+}
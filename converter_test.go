@@ -0,0 +1,79 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type upper string
+
+func convertUpper(src interface{}) (interface{}, error) {
+	if text, ok := src.(string); ok {
+		return upper(strings.ToUpper(text)), nil
+	}
+	return nil, fmt.Errorf("not string")
+}
+
+func TestRegisterConverter(t *testing.T) {
+	typ := reflect.TypeOf(upper(""))
+	defer delete(converters, typ)
+	converter := NewGeneralConverter(typ, convertUpper)
+	RegisterConverter(converter)
+	if c := converters[typ]; c != converter {
+		t.Error("register converter fail:", c)
+		return
+	}
+	var a upper
+	if err := Unmarshal(&a, "hello"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != "HELLO" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// same type
+	if err := Unmarshal(&a, upper("world")); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != "world" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// convert fail
+	if err := Unmarshal(&a, 1); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// map key and set element
+	var b map[upper]upper
+	if err := Unmarshal(&b, map[string]interface{}{"a": "b"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b["A"] != "B" {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+	var c map[upper]bool
+	if err := Unmarshal(&c, []string{"a", "b"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !c["A"] || !c["B"] {
+		t.Error("unexpected unmarshal result:", c)
+		return
+	}
+}
+
+func TestConverterBadResult(t *testing.T) {
+	typ := reflect.TypeOf(upper(""))
+	defer delete(converters, typ)
+	RegisterConverter(NewGeneralConverter(typ, func(src interface{}) (interface{}, error) {
+		return 1, nil
+	}))
+	var a upper
+	if err := Unmarshal(&a, "hello"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
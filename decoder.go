@@ -0,0 +1,164 @@
+package map2struct
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Decoder holds the options used in unmarshaling.
+// A Decoder should not be modified while it is in use.
+type Decoder struct {
+	// TimeLayouts are the layouts tried in order when parsing time text.
+	// A field can override them with the `layout` tag.
+	TimeLayouts []string
+
+	// TimeLocation is the location used for time layouts without time zone.
+	// UTC is used if it is nil.
+	TimeLocation *time.Location
+
+	// TimestampUnit is the unit of numeric timestamps. Default is time.Second.
+	TimestampUnit time.Duration
+
+	// Now returns the current time used in relative time expressions like "now-1h".
+	Now func() time.Time
+
+	// FloatValues are the special float texts like "Inf" and "NaN".
+	FloatValues map[string]float64
+
+	// FloatSuffixes are the scale suffixes of float texts like "%" and "bp".
+	// The number before a suffix is divided by the scale, e.g. 100 for "%".
+	FloatSuffixes map[string]float64
+
+	// DurationValues are the special duration texts like "genesis" and "doomsday".
+	DurationValues map[string]time.Duration
+
+	// NilMode defines how nil source values are unmarshaled. Default is NilZero.
+	NilMode NilMode
+
+	// SliceMerge defines how source values are merged into existing slices.
+	// Default is MergeTruncate.
+	SliceMerge MergeStrategy
+
+	// MapMerge defines how source values are merged into existing maps.
+	// Default is MergeMerge.
+	MapMerge MergeStrategy
+
+	// Separator splits text into items of slices, sets and maps, like "a,b,c".
+	// A field can override it with the `sep` tag. Empty separator disables splitting.
+	Separator string
+
+	// KeyValueSeparator splits items of text into keys and values of maps, like "k1=v1,k2=v2".
+	// A field can override it with the `kvsep` tag.
+	KeyValueSeparator string
+
+	// KeySeparator splits flattened keys of struct sources, like "db.primary.host"
+	// and "servers.0.port". Keys matching field names are not split.
+	// Empty separator disables flattened keys.
+	KeySeparator string
+
+	// Resolvers resolve placeholders like "${DB_HOST}" and "${DB_HOST:-localhost}"
+	// in string values, in order. "$$" escapes "$". Nil disables interpolation.
+	Resolvers []Resolver
+
+	// ResolveSecrets resolves references of registered secret schemes like "vault:path#key"
+	// in all string values, not only in fields tagged `secret`. Errors of the values are redacted.
+	// Any value of the source can read secrets and files of the resolvers, so sources must be
+	// trusted. Form values like url.Values are never resolved as references.
+	ResolveSecrets bool
+
+	// root is the source being unmarshaled, for SourceResolver.
+	root reflect.Value
+
+	// origins are the origins of decoded fields by paths, nil if not recorded.
+	origins map[string]Origin
+
+	// path is the path of the value being unmarshaled, if origins are recorded.
+	path string
+
+	// patching is true in Patch, see Decoder.Patch.
+	patching bool
+
+	// literal is true for untrusted sources like form values, whose secret references are not resolved.
+	literal bool
+}
+
+// NilMode defines how nil source values are unmarshaled.
+type NilMode int
+
+const (
+	// NilZero resets the destination to its zero value,
+	// pointers, maps, slices and interfaces are set to nil.
+	NilZero NilMode = iota
+	// NilIgnore leaves the destination unchanged.
+	NilIgnore
+	// NilError reports nil source values as errors.
+	NilError
+)
+
+var (
+	defaultDecoder = NewDecoder()
+)
+
+// NewDecoder creates a Decoder with default options.
+func NewDecoder() *Decoder {
+	layouts := make([]string, len(defaultTimeLayouts))
+	copy(layouts, defaultTimeLayouts)
+	return &Decoder{
+		TimeLayouts:   layouts,
+		TimestampUnit: time.Second,
+		Now:           time.Now,
+		FloatValues: map[string]float64{
+			"Inf":  math.Inf(1),
+			"+Inf": math.Inf(1),
+			"-Inf": math.Inf(-1),
+			"NaN":  math.NaN(),
+		},
+		FloatSuffixes: map[string]float64{
+			"%":   100,
+			"‰":   1000,
+			"bp":  10000,
+			"bps": 10000,
+		},
+		DurationValues: map[string]time.Duration{
+			"genesis":  math.MinInt64,
+			"doomsday": math.MaxInt64,
+		},
+		SliceMerge:        MergeTruncate,
+		MapMerge:          MergeMerge,
+		Separator:         ",",
+		KeyValueSeparator: "=",
+		KeySeparator:      ".",
+	}
+}
+
+// Unmarshal unmarshal map[string]interface{} to a struct instance
+func Unmarshal(dest, src interface{}) error {
+	return defaultDecoder.Unmarshal(dest, src)
+}
+
+// Unmarshal unmarshal src to dest with the options of the decoder.
+// The dest must be a non-nil pointer. Form values like url.Values are decoded as
+// nested data: single values are unwrapped, repeated keys are lists, and bracketed
+// keys like "filter[status]" and "ids[]" are nested maps and lists. Form values are
+// neither interpolated nor resolved as secret references, even for fields tagged `secret`.
+func (decoder *Decoder) Unmarshal(dest, src interface{}) error {
+	if value := reflect.ValueOf(dest); value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("invalid destination: expect non-nil pointer but found %T", dest)
+	}
+	value := reflect.ValueOf(src)
+	// form values are decoded as nested data unless assigned to form values
+	if destType := reflect.TypeOf(dest).Elem(); value.IsValid() && isFormValues(value.Type()) &&
+		(destType.Kind() == reflect.Interface || !value.Type().ConvertibleTo(destType)) {
+		data, err := decoder.formData(value)
+		if err != nil {
+			return err
+		}
+		value = reflect.ValueOf(data)
+		literal := *decoder
+		literal.literal = true
+		decoder = &literal
+	}
+	return decoder.withSource(value).unmarshal(rvalue(dest), value, "")
+}
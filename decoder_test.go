@@ -0,0 +1,129 @@
+package map2struct
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecoderFloatValues(t *testing.T) {
+	decoder := NewDecoder()
+	decoder.FloatValues = map[string]float64{
+		"max": math.MaxFloat64,
+	}
+	var a float64
+	if err := decoder.Unmarshal(&a, "max"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != math.MaxFloat64 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// disabled
+	a = 0
+	if err := decoder.Unmarshal(&a, "NaN"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "+Inf"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestDecoderFloatSuffixes(t *testing.T) {
+	var a float64
+	cases := map[string]float64{
+		"-5%":   -0.05,
+		"1e3%":  10,
+		"2.5 %": 0.025,
+		"3‰":    0.003,
+		"25bp":  0.0025,
+		"50bps": 0.005,
+	}
+	for text, expect := range cases {
+		if err := Unmarshal(&a, text); err != nil {
+			t.Error("unmarshal fail:", text, err.Error())
+			return
+		} else if math.Abs(a-expect) > 1e-12 {
+			t.Error("unexpected unmarshal result:", text, a)
+			return
+		}
+	}
+	if err := Unmarshal(&a, "abc%"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	decoder := NewDecoder()
+	decoder.FloatSuffixes = map[string]float64{
+		"k": 0.001,
+	}
+	if err := decoder.Unmarshal(&a, "1.5k"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != 1500 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "15%"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestDecoderDurationValues(t *testing.T) {
+	decoder := NewDecoder()
+	decoder.DurationValues["forever"] = math.MaxInt64
+	delete(decoder.DurationValues, "genesis")
+	var a time.Duration
+	if err := decoder.Unmarshal(&a, "forever"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != math.MaxInt64 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "genesis"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// default decoder is not affected
+	if err := Unmarshal(&a, "genesis"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+}
+
+func TestDecoderNilMode(t *testing.T) {
+	type TestStruct struct {
+		Int int
+		Ptr *S1
+	}
+	src := map[string]interface{}{
+		"Int": nil,
+		"Ptr": nil,
+	}
+	decoder := NewDecoder()
+	decoder.NilMode = NilIgnore
+	a := TestStruct{Int: 1, Ptr: &S1{}}
+	if err := decoder.Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Int != 1 || a.Ptr == nil {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	decoder.NilMode = NilError
+	if err := decoder.Unmarshal(&a, src); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	decoder.NilMode = NilZero
+	if err := decoder.Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Int != 0 || a.Ptr != nil {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+}
@@ -0,0 +1,186 @@
+package map2struct
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ChangeKind is the kind of a change between two values.
+type ChangeKind string
+
+const (
+	// ChangeAdded is a value added, like a map key, a slice element or a non-nil pointer.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a value removed.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is a value modified.
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a change between two values.
+type Change struct {
+	// Path is the path of the value, like "Servers[0].Port" of errors.
+	Path string
+
+	Kind ChangeKind
+
+	// Old and New are the values before and after the change, nil for added and removed values.
+	// Values of sensitive fields are Redacted.
+	Old interface{}
+	New interface{}
+}
+
+func (change Change) String() string {
+	switch change.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s: added %v", change.Path, change.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s: removed %v", change.Path, change.Old)
+	}
+	return fmt.Sprintf("%s: %v -> %v", change.Path, change.Old, change.New)
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Diff returns the changes from the old value to the new value, in the order of
+// fields, indexes and sorted map keys. Structs, maps, slices and pointers are compared
+// by elements, and the other values are compared as a whole, like times by
+// time.Time.Equal, durations, and types with converters or text marshalers.
+// Sets of maps like map[string]bool report added and removed keys. Values of different
+// types, like interface values of different factory types, are modified as a whole.
+func Diff(old, new interface{}) []Change {
+	var changes []Change
+	diff(&changes, "", reflect.ValueOf(old), reflect.ValueOf(new), false)
+	return changes
+}
+
+func diff(changes *[]Change, path string, old, new reflect.Value, sensitive bool) {
+	for old.IsValid() && (old.Kind() == reflect.Interface || old.Kind() == reflect.Ptr) && !old.IsNil() &&
+		new.IsValid() && new.Kind() == old.Kind() && !new.IsNil() && (old.Kind() == reflect.Ptr || old.Elem().Type() == new.Elem().Type()) {
+		old, new = old.Elem(), new.Elem()
+	}
+	switch {
+	case isNil(old) && isNil(new):
+		return
+	case isNil(old):
+		*changes = append(*changes, Change{Path: path, Kind: ChangeAdded, New: changeValue(new, sensitive)})
+		return
+	case isNil(new):
+		*changes = append(*changes, Change{Path: path, Kind: ChangeRemoved, Old: changeValue(old, sensitive)})
+		return
+	case old.Type() != new.Type():
+		modified(changes, path, old, new, sensitive)
+		return
+	}
+	typ := old.Type()
+	if typ == timeType {
+		if !old.Interface().(time.Time).Equal(new.Interface().(time.Time)) {
+			modified(changes, path, old, new, sensitive)
+		}
+		return
+	} else if converters[typ] != nil || typ.Implements(textMarshalerType) {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			modified(changes, path, old, new, sensitive)
+		}
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		diffStruct(changes, path, old, new, sensitive)
+	case reflect.Map:
+		diffMap(changes, path, old, new, sensitive)
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+				modified(changes, path, old, new, sensitive)
+			}
+			return
+		}
+		for i := 0; i < old.Len() || i < new.Len(); i++ {
+			elemPath := joinPath(path, indexSegment(i))
+			if i >= new.Len() {
+				*changes = append(*changes, Change{Path: elemPath, Kind: ChangeRemoved, Old: changeValue(old.Index(i), sensitive)})
+			} else if i >= old.Len() {
+				*changes = append(*changes, Change{Path: elemPath, Kind: ChangeAdded, New: changeValue(new.Index(i), sensitive)})
+			} else {
+				diff(changes, elemPath, old.Index(i), new.Index(i), sensitive)
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if a, b := old.Float(), new.Float(); a != b && !(math.IsNaN(a) && math.IsNaN(b)) {
+			modified(changes, path, old, new, sensitive)
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		// not decoded
+	default:
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			modified(changes, path, old, new, sensitive)
+		}
+	}
+}
+
+func diffStruct(changes *[]Change, path string, old, new reflect.Value, sensitive bool) {
+	typ := old.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && (field.PkgPath == "" || field.Type.Kind() == reflect.Struct) {
+			// fields of anonymous field are promoted, so the path is unchanged
+			diff(changes, path, old.Field(i), new.Field(i), sensitive || isSensitive(field.Tag))
+		} else if field.PkgPath == "" {
+			diff(changes, joinPath(path, field.Name), old.Field(i), new.Field(i), sensitive || isSensitive(field.Tag))
+		}
+	}
+}
+
+func diffMap(changes *[]Change, path string, old, new reflect.Value, sensitive bool) {
+	// keys are compared by values, like 1 and "1" of map[interface{}]interface{},
+	// and sorted by texts
+	seen := make(map[interface{}]bool)
+	var keys []reflect.Value
+	for _, key := range append(old.MapKeys(), new.MapKeys()...) {
+		if !seen[key.Interface()] {
+			seen[key.Interface()] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i].Interface(), keys[j].Interface()
+		if textA, textB := fmt.Sprint(a), fmt.Sprint(b); textA != textB {
+			return textA < textB
+		}
+		return fmt.Sprintf("%T", a) < fmt.Sprintf("%T", b)
+	})
+	for _, key := range keys {
+		oldValue, newValue := old.MapIndex(key), new.MapIndex(key)
+		keyPath := joinPath(path, keySegment(key.Interface()))
+		if !oldValue.IsValid() {
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeAdded, New: changeValue(newValue, sensitive)})
+		} else if !newValue.IsValid() {
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeRemoved, Old: changeValue(oldValue, sensitive)})
+		} else {
+			diff(changes, keyPath, oldValue, newValue, sensitive)
+		}
+	}
+}
+
+func modified(changes *[]Change, path string, old, new reflect.Value, sensitive bool) {
+	*changes = append(*changes, Change{
+		Path: path,
+		Kind: ChangeModified,
+		Old:  changeValue(old, sensitive),
+		New:  changeValue(new, sensitive),
+	})
+}
+
+func changeValue(value reflect.Value, sensitive bool) interface{} {
+	if sensitive {
+		return Redacted
+	} else if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
@@ -0,0 +1,95 @@
+package map2struct
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testDiffServer struct {
+	Host string
+	Port int
+}
+
+type testDiffConfig struct {
+	TestEmbedTypeB
+	Name     string
+	Timeout  time.Duration
+	Started  time.Time
+	Ratio    float64
+	Password string `sensitive:""`
+	Server   *testDiffServer
+	Servers  []testDiffServer
+	Tags     map[string]bool
+	Labels   map[string]string
+	Data     []byte
+	Handler  stringer
+	handler  stringer
+}
+
+func TestDiff(t *testing.T) {
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	old := testDiffConfig{
+		TestEmbedTypeB: TestEmbedTypeB{I: 1},
+		Name:           "app",
+		Timeout:        time.Second,
+		Started:        started,
+		Ratio:          math.NaN(),
+		Password:       "a",
+		Servers:        []testDiffServer{{"a", 80}, {"b", 81}},
+		Tags:           map[string]bool{"x": true, "y": true},
+		Labels:         map[string]string{"k": "v"},
+		Data:           []byte("abc"),
+		Handler:        foo{Text: "a"},
+	}
+	new := old
+	new.I = 2
+	new.Timeout = time.Minute
+	new.Started = started.In(time.FixedZone("X", 3600))
+	new.Ratio = math.NaN()
+	new.Password = "b"
+	new.Server = &testDiffServer{Host: "c"}
+	new.Servers = []testDiffServer{{"a", 8080}}
+	new.Tags = map[string]bool{"y": true, "z": true}
+	new.Labels = nil
+	new.Data = []byte("abd")
+	new.Handler = bar{Duration: time.Second}
+	new.handler = foo{}
+	expect := []Change{
+		{Path: "I", Kind: ChangeModified, Old: 1, New: 2},
+		{Path: "Timeout", Kind: ChangeModified, Old: time.Second, New: time.Minute},
+		{Path: "Password", Kind: ChangeModified, Old: Redacted, New: Redacted},
+		{Path: "Server", Kind: ChangeAdded, New: &testDiffServer{Host: "c"}},
+		{Path: "Servers[0].Port", Kind: ChangeModified, Old: 80, New: 8080},
+		{Path: "Servers[1]", Kind: ChangeRemoved, Old: testDiffServer{"b", 81}},
+		{Path: "Tags[x]", Kind: ChangeRemoved, Old: true},
+		{Path: "Tags[z]", Kind: ChangeAdded, New: true},
+		{Path: "Labels[k]", Kind: ChangeRemoved, Old: "v"},
+		{Path: "Data", Kind: ChangeModified, Old: []byte("abc"), New: []byte("abd")},
+		{Path: "Handler", Kind: ChangeModified, Old: foo{Text: "a"}, New: bar{Duration: time.Second}},
+	}
+	if changes := Diff(old, new); !reflect.DeepEqual(changes, expect) {
+		t.Error("unexpected changes:", changes)
+		return
+	}
+	if changes := Diff(&old, &old); len(changes) != 0 {
+		t.Error("unexpected changes:", changes)
+		return
+	}
+	if changes := Diff(&old, nil); len(changes) != 1 || changes[0].Kind != ChangeRemoved || changes[0].Path != "" {
+		t.Error("unexpected changes:", changes)
+		return
+	}
+	changes := Diff(map[string]interface{}{"a": 1, "b": []interface{}{"x"}}, map[string]interface{}{"a": "1", "b": []interface{}{"y"}})
+	if len(changes) != 2 || changes[0].String() != "[a]: 1 -> 1" || changes[1].String() != "[b][0]: x -> y" {
+		t.Error("unexpected changes:", changes)
+		return
+	}
+	// keys of different types with the same text
+	changes = Diff(map[interface{}]interface{}{1: "a", "1": "b"}, map[interface{}]interface{}{1: "x", "1": "y"})
+	if len(changes) != 2 || changes[0].String() != "[1]: a -> x" || changes[1].String() != "[1]: b -> y" {
+		t.Error("unexpected changes:", changes)
+		return
+	}
+}
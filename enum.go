@@ -0,0 +1,204 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Enum represents a enumeration type with named values.
+// Enum values are unmarshaled from their names and marshaled back to names.
+type Enum struct {
+	enumType        reflect.Type
+	values          map[string]reflect.Value
+	names           map[interface{}]string
+	caseInsensitive bool
+	flags           bool
+}
+
+var (
+	enums = make(map[reflect.Type]*Enum)
+
+	flagSeparatorPattern = regexp.MustCompile("[|,]")
+)
+
+// RegisterEnum register a enumeration type with its named values.
+// The values must be convertible to the enumeration type, RegisterEnum panics otherwise.
+// Texts of integer types are also unmarshaled from plain integers like "1".
+func RegisterEnum(enumType reflect.Type, values map[string]interface{}) *Enum {
+	enum := &Enum{
+		enumType: enumType,
+		values:   make(map[string]reflect.Value),
+		names:    make(map[interface{}]string),
+	}
+	for name, value := range values {
+		enumValue := reflect.ValueOf(value)
+		if !enumValue.IsValid() || !enumValue.Type().ConvertibleTo(enumType) {
+			panic(fmt.Sprintf("enum value %q of %s is %T", name, enumType, value))
+		}
+		enumValue = enumValue.Convert(enumType)
+		enum.values[name] = enumValue
+		if previous, found := enum.names[enumValue.Interface()]; !found || name < previous {
+			enum.names[enumValue.Interface()] = name
+		}
+	}
+	enums[enumType] = enum
+	return enum
+}
+
+// CaseInsensitive makes names of the enumeration matched case-insensitively.
+func (enum *Enum) CaseInsensitive() *Enum {
+	enum.caseInsensitive = true
+	return enum
+}
+
+// Flags makes the enumeration a bit-flag type. Flag values are unmarshaled
+// from names separated by "|" or "," like "READ|WRITE", or from a list of names,
+// and the values of the names are OR-ed. Flags panics if the enumeration type
+// is not an integer type.
+func (enum *Enum) Flags() *Enum {
+	if !isIntKind(enum.enumType.Kind()) {
+		panic(fmt.Sprintf("flag enum %s is not integer type", enum.enumType))
+	}
+	enum.flags = true
+	return enum
+}
+
+// Names returns the sorted names of the enumeration.
+func (enum *Enum) Names() []string {
+	names := make([]string, 0, len(enum.values))
+	for name := range enum.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name returns the name of a enumeration value.
+func (enum *Enum) Name(value interface{}) (string, bool) {
+	enumValue := reflect.ValueOf(value)
+	if !enumValue.IsValid() || !enumValue.Type().ConvertibleTo(enum.enumType) {
+		return "", false
+	}
+	enumValue = enumValue.Convert(enum.enumType)
+	if name, found := enum.names[enumValue.Interface()]; found || !enum.flags {
+		return name, found
+	}
+	return enum.flagNames(bitsOf(enumValue))
+}
+
+// flagNames decomposes bits to names of flags, larger flags are taken first.
+func (enum *Enum) flagNames(bits uint64) (string, bool) {
+	names := enum.Names()
+	sort.SliceStable(names, func(i, j int) bool {
+		return bitsOf(enum.values[names[i]]) > bitsOf(enum.values[names[j]])
+	})
+	var flags []string
+	remaining := bits
+	for _, name := range names {
+		if flag := bitsOf(enum.values[name]); flag != 0 && flag&remaining == flag {
+			flags = append(flags, name)
+			remaining &^= flag
+		}
+	}
+	if remaining != 0 || len(flags) == 0 {
+		return "", false
+	}
+	for i, j := 0, len(flags)-1; i < j; i, j = i+1, j-1 {
+		flags[i], flags[j] = flags[j], flags[i]
+	}
+	return strings.Join(flags, "|"), true
+}
+
+func (enum *Enum) lookup(name string) (reflect.Value, bool) {
+	if value, found := enum.values[name]; found {
+		return value, true
+	} else if enum.caseInsensitive {
+		for key, value := range enum.values {
+			if strings.EqualFold(key, name) {
+				return value, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (enum *Enum) unmarshal(dest reflect.Value, name string) error {
+	if enum.flags {
+		return enum.unmarshalFlags(dest, reflect.ValueOf(flagSeparatorPattern.Split(name, -1)))
+	}
+	value, err := enum.value(name)
+	if err != nil {
+		return err
+	}
+	dest.Set(value)
+	return nil
+}
+
+// unmarshalFlags unmarshal a list of flag names or integers.
+func (enum *Enum) unmarshalFlags(dest, src reflect.Value) error {
+	var bits uint64
+	for i := 0; i < src.Len(); i++ {
+		item := src.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		switch kind := item.Kind(); {
+		case kind == reflect.String:
+			name := strings.TrimSpace(item.String())
+			if name == "" {
+				continue
+			}
+			value, err := enum.value(name)
+			if err != nil {
+				return err
+			}
+			bits |= bitsOf(value)
+		case isIntKind(kind):
+			bits |= bitsOf(item)
+		default:
+			return fmt.Errorf("flag item [%d] error: %s", i, badtype("int/string", item))
+		}
+	}
+	if dest.Kind() >= reflect.Int && dest.Kind() <= reflect.Int64 {
+		dest.SetInt(int64(bits))
+	} else {
+		dest.SetUint(bits)
+	}
+	return nil
+}
+
+func (enum *Enum) value(name string) (reflect.Value, error) {
+	value, found := enum.lookup(name)
+	if kind := enum.enumType.Kind(); !found && isIntKind(kind) {
+		// plain integers are accepted like types without enumerations
+		value = reflect.New(enum.enumType).Elem()
+		if kind <= reflect.Int64 {
+			number, err := parseIntText(name)
+			found = err == nil && !value.OverflowInt(number)
+			value.SetInt(number)
+		} else {
+			number, err := parseUintText(name)
+			found = err == nil && !value.OverflowUint(number)
+			value.SetUint(number)
+		}
+	}
+	if !found {
+		return reflect.Value{}, fmt.Errorf("unknown %s value %q: valid values are %s",
+			enum.enumType, name, strings.Join(enum.Names(), ", "))
+	}
+	return value, nil
+}
+
+func bitsOf(value reflect.Value) uint64 {
+	if value.Kind() >= reflect.Int && value.Kind() <= reflect.Int64 {
+		return uint64(value.Int())
+	}
+	return value.Uint()
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uint64
+}
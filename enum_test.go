@@ -0,0 +1,209 @@
+package map2struct
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type level int
+
+const (
+	levelDebug level = iota
+	levelInfo
+	levelError
+)
+
+type color string
+
+func TestRegisterEnum(t *testing.T) {
+	levelType := reflect.TypeOf(levelDebug)
+	defer delete(enums, levelType)
+	enum := RegisterEnum(levelType, map[string]interface{}{
+		"debug": levelDebug,
+		"info":  levelInfo,
+		"error": 2,
+	})
+	if e := enums[levelType]; e != enum {
+		t.Error("register enum fail:", e)
+		return
+	}
+	type TestStruct struct {
+		Level  level
+		Levels []level
+		Counts map[level]int
+	}
+	src := map[string]interface{}{
+		"Level":  "error",
+		"Levels": []interface{}{"debug", 1},
+		"Counts": map[string]interface{}{
+			"info": 3,
+		},
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Level != levelError || len(a.Levels) != 2 || a.Levels[0] != levelDebug || a.Levels[1] != levelInfo || a.Counts[levelInfo] != 3 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// plain integers
+	if err := Unmarshal(&a, map[string]interface{}{"Level": "1", "Levels": "0,2"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Level != levelInfo || len(a.Levels) != 2 || a.Levels[0] != levelDebug || a.Levels[1] != levelError {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// unknown name
+	var b level
+	if err := Unmarshal(&b, "warn"); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	} else if !strings.Contains(err.Error(), "debug, error, info") {
+		t.Error("unexpected error:", err.Error())
+		return
+	}
+	// case sensitive
+	if err := Unmarshal(&b, "INFO"); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+	enum.CaseInsensitive()
+	if err := Unmarshal(&b, "INFO"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b != levelInfo {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+	if name, found := enum.Name(levelError); !found || name != "error" {
+		t.Error("unexpected name:", name, found)
+		return
+	}
+	if name, found := enum.Name(level(10)); found {
+		t.Error("unexpected name:", name)
+		return
+	}
+}
+
+func TestRegisterStringEnum(t *testing.T) {
+	colorType := reflect.TypeOf(color(""))
+	defer delete(enums, colorType)
+	RegisterEnum(colorType, map[string]interface{}{
+		"red":   "#ff0000",
+		"green": "#00ff00",
+	})
+	var a color
+	if err := Unmarshal(&a, "red"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != "#ff0000" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, "blue"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestRegisterEnumPanic(t *testing.T) {
+	levelType := reflect.TypeOf(levelDebug)
+	defer delete(enums, levelType)
+	defer func() {
+		if recover() == nil {
+			t.Error("unexpected register success")
+		}
+	}()
+	RegisterEnum(levelType, map[string]interface{}{
+		"debug": "0",
+	})
+}
+
+type permission uint8
+
+const (
+	permissionRead permission = 1 << iota
+	permissionWrite
+	permissionExecute
+)
+
+func TestRegisterFlagEnum(t *testing.T) {
+	permissionType := reflect.TypeOf(permissionRead)
+	defer delete(enums, permissionType)
+	enum := RegisterEnum(permissionType, map[string]interface{}{
+		"READ":    permissionRead,
+		"WRITE":   permissionWrite,
+		"EXECUTE": permissionExecute,
+		"ALL":     permissionRead | permissionWrite | permissionExecute,
+	}).CaseInsensitive().Flags()
+	var a permission
+	if err := Unmarshal(&a, "READ|WRITE"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != permissionRead|permissionWrite {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, "read, execute"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != permissionRead|permissionExecute {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, []interface{}{"write", 4}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != permissionWrite|permissionExecute {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, 3); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != permissionRead|permissionWrite {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, "READ|DELETE"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	if err := Unmarshal(&a, []interface{}{true}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	if name, found := enum.Name(permissionRead | permissionExecute); !found || name != "READ|EXECUTE" {
+		t.Error("unexpected name:", name, found)
+		return
+	}
+	if name, found := enum.Name(permissionRead | permissionWrite | permissionExecute); !found || name != "ALL" {
+		t.Error("unexpected name:", name, found)
+		return
+	}
+	if name, found := enum.Name(permission(8)); found {
+		t.Error("unexpected name:", name)
+		return
+	}
+	if result, err := Marshal(permissionWrite | permissionExecute); err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	} else if result != "WRITE|EXECUTE" {
+		t.Error("unexpected marshal result:", result)
+		return
+	}
+}
+
+func TestFlagEnumPanic(t *testing.T) {
+	colorType := reflect.TypeOf(color(""))
+	defer delete(enums, colorType)
+	defer func() {
+		if recover() == nil {
+			t.Error("unexpected flags success")
+		}
+	}()
+	RegisterEnum(colorType, map[string]interface{}{}).Flags()
+}
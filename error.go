@@ -0,0 +1,54 @@
+package map2struct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error represents a unmarshaling error with the path of the source value.
+type Error struct {
+	// Path is the path of the source value from the root, like "Servers[0].Port".
+	Path string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (err *Error) Error() string {
+	if err.Path == "" {
+		return err.Err.Error()
+	}
+	return err.Path + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// pathError prepends a path segment to the path of the error.
+func pathError(segment string, err error) error {
+	if pathErr, ok := err.(*Error); ok {
+		return &Error{Path: joinPath(segment, pathErr.Path), Err: pathErr.Err}
+	}
+	return &Error{Path: segment, Err: err}
+}
+
+func joinPath(parent, child string) string {
+	if parent == "" {
+		return child
+	} else if child == "" {
+		return parent
+	} else if strings.HasPrefix(child, "[") {
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+func indexSegment(index int) string {
+	return fmt.Sprintf("[%d]", index)
+}
+
+func keySegment(key interface{}) string {
+	return fmt.Sprintf("[%v]", key)
+}
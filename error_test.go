@@ -0,0 +1,106 @@
+package map2struct
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	type TestStruct struct {
+		S2
+		Slice []map[string]int
+	}
+	var a TestStruct
+	// path of anonymous field is unchanged
+	err := Unmarshal(&a, map[string]interface{}{"Duration": true})
+	if pathErr, ok := err.(*Error); !ok || pathErr.Path != "Duration" {
+		t.Error("unexpected error:", err)
+		return
+	}
+	err = Unmarshal(&a, map[string]interface{}{
+		"Slice": []interface{}{
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"b": "x"},
+		},
+	})
+	if pathErr, ok := err.(*Error); !ok || pathErr.Path != "Slice[1][b]" {
+		t.Error("unexpected error:", err)
+		return
+	} else if !strings.HasPrefix(err.Error(), "Slice[1][b]: ") {
+		t.Error("unexpected error message:", err.Error())
+		return
+	} else if errors.Unwrap(err) != pathErr.Err {
+		t.Error("unexpected unwrap result:", errors.Unwrap(err))
+		return
+	}
+}
+
+func TestUnmarshalInvalidDestination(t *testing.T) {
+	var a S1
+	if err := Unmarshal(a, map[string]interface{}{}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	if err := Unmarshal((*S1)(nil), map[string]interface{}{}); err == nil {
+		t.Error("unexpected unmarshal success")
+		return
+	}
+	if err := Unmarshal(nil, map[string]interface{}{}); err == nil {
+		t.Error("unexpected unmarshal success")
+		return
+	}
+}
+
+func TestUnmarshalUnexportedField(t *testing.T) {
+	type TestStruct struct {
+		Public  int
+		private int
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, map[string]interface{}{"Public": 1, "private": 2}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Public != 1 || a.private != 0 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+}
+
+func TestUnmarshalRecoverPanic(t *testing.T) {
+	typ := reflect.TypeOf(upper(""))
+	defer delete(converters, typ)
+	RegisterConverter(NewGeneralConverter(typ, func(src interface{}) (interface{}, error) {
+		panic("boom")
+	}))
+	type TestStruct struct {
+		Items []upper
+	}
+	var a TestStruct
+	err := Unmarshal(&a, map[string]interface{}{"Items": []string{"a"}})
+	if pathErr, ok := err.(*Error); !ok || pathErr.Path != "Items[0]" || !strings.Contains(err.Error(), "boom") {
+		t.Error("unexpected error:", err)
+		return
+	}
+}
+
+type badFactory struct{}
+
+func (factory badFactory) GetInstanceType() reflect.Type {
+	return reflect.TypeOf((*stringer)(nil)).Elem()
+}
+
+func (factory badFactory) Create(data map[string]interface{}) (interface{}, error) {
+	return 1, nil
+}
+
+func TestUnmarshalBadFactory(t *testing.T) {
+	defer func() { factories = make(map[string]Factory) }()
+	RegisterFactory(badFactory{})
+	var a stringer
+	if err := Unmarshal(&a, map[string]interface{}{}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
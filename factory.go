@@ -24,9 +24,16 @@ func RegisterFactory(factory Factory) {
 	factories[getTypeName(factory.GetInstanceType())] = factory
 }
 
-func createByFactory(typ reflect.Type, data map[string]interface{}) (interface{}, error) {
+// decoderFactory is implemented by factories decoding instances with the options of the active decoder.
+type decoderFactory interface {
+	createWith(decoder *Decoder, data map[string]interface{}) (interface{}, error)
+}
+
+func (decoder *Decoder) createByFactory(typ reflect.Type, data map[string]interface{}) (interface{}, error) {
 	typeName := getTypeName(typ)
-	if factory := factories[typeName]; factory != nil {
+	if factory, ok := factories[typeName].(decoderFactory); ok {
+		return factory.createWith(decoder, data)
+	} else if factory := factories[typeName]; factory != nil {
 		return factory.Create(data)
 	}
 	return nil, fmt.Errorf("unregistered type: %q", typeName)
@@ -78,9 +85,14 @@ func (factory *GeneralInterfaceFactory) GetInstanceType() reflect.Type {
 
 // Create creates a new instance implement the interface.
 func (factory *GeneralInterfaceFactory) Create(data map[string]interface{}) (interface{}, error) {
+	return factory.createWith(defaultDecoder, data)
+}
+
+// createWith creates a new instance unmarshaled by the decoder.
+func (factory *GeneralInterfaceFactory) createWith(decoder *Decoder, data map[string]interface{}) (interface{}, error) {
 	var instance interface{}
 	if typeName, ok := data[factory.typeKey].(string); !ok || typeName == "" {
-		return nil, fmt.Errorf("missing type key: key=%q, map=%v", factory.typeKey, data)
+		return nil, fmt.Errorf("missing type key: %q", factory.typeKey)
 	} else if registeredInstance, found := factory.instances[typeName]; found {
 		return registeredInstance, nil
 	} else if instanceType, found := factory.types[typeName]; !found {
@@ -90,7 +102,7 @@ func (factory *GeneralInterfaceFactory) Create(data map[string]interface{}) (int
 	} else {
 		instance = reflect.New(instanceType).Interface()
 	}
-	if err := Unmarshal(instance, data); err != nil {
+	if err := decoder.unmarshal(rvalue(instance), reflect.ValueOf(data), ""); err != nil {
 		return nil, fmt.Errorf("unmarshal map fail: %s", err.Error())
 	}
 	if factory.initializer != nil {
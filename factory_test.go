@@ -88,6 +88,18 @@ func TestGeneralInterfaceFactory(t *testing.T) {
 		t.Error("unexpected output:", output)
 		return
 	}
+	// instances are unmarshaled with the options of the decoder
+	decoder := NewDecoder()
+	decoder.Resolvers = []Resolver{MapResolver(map[string]string{"TEXT": "decoder"})}
+	if err := decoder.Unmarshal(&output, map[string]interface{}{
+		"Stringer1": map[string]interface{}{"type": "Foo", "Text": "${TEXT}"},
+	}); err != nil {
+		t.Error("unmarshal map fail:", err.Error())
+		return
+	} else if output.Stringer1.String() != "foo:decoder" {
+		t.Error("unexpected output:", output.Stringer1)
+		return
+	}
 
 	src = map[string]interface{}{
 		"type": "Foo",
@@ -127,6 +139,16 @@ func TestGeneralInterfaceFactory(t *testing.T) {
 		return
 	}
 
+	// test non-string-keyed map
+	if err := Unmarshal(&s, map[interface{}]interface{}{"type": "Foo", "Text": "yaml"}); err != nil {
+		t.Error("unmarshal map fail:", err.Error())
+		return
+	}
+	if s.String() != "foo:yaml" {
+		t.Error("unexpected output:", s)
+		return
+	}
+
 	// test ptr
 	src = map[string]interface{}{
 		"type": "Foo",
@@ -0,0 +1,186 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten flattens nested maps and slices to a map with keys joined by the separator,
+// like {"db.host": "x", "servers.0.port": 80}. Empty maps and slices are kept as values.
+func Flatten(data map[string]interface{}, sep string) map[string]interface{} {
+	result := make(map[string]interface{})
+	// the root is not a value, so an empty root is flattened to an empty map
+	for key, item := range data {
+		flatten(result, key, reflect.ValueOf(item), sep)
+	}
+	return result
+}
+
+func flatten(result map[string]interface{}, prefix string, value reflect.Value, sep string) {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map:
+		if data, err := toStringMap(value); err == nil && len(data) > 0 {
+			for key, item := range data {
+				flatten(result, prefix+sep+key, reflect.ValueOf(item), sep)
+			}
+			return
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() != reflect.Uint8 && value.Len() > 0 {
+			for i := 0; i < value.Len(); i++ {
+				flatten(result, prefix+sep+strconv.Itoa(i), value.Index(i), sep)
+			}
+			return
+		}
+	}
+	if value.IsValid() {
+		result[prefix] = value.Interface()
+	} else {
+		result[prefix] = nil
+	}
+}
+
+// Unflatten builds nested maps from keys joined by the separator, the reverse of Flatten.
+// Maps whose keys are exactly the indexes 0 to n-1 are converted to slices.
+func Unflatten(data map[string]interface{}, sep string) (map[string]interface{}, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("empty separator")
+	}
+	result := make(map[string]interface{})
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	// prefixes sort first, so conflicts are reported on the longer keys
+	sort.Strings(keys)
+	for _, key := range keys {
+		node := result
+		segments := strings.Split(key, sep)
+		for i, segment := range segments[:len(segments)-1] {
+			child, found := node[segment]
+			if !found {
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			childMap, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("conflict key %q: %q is not a map",
+					key, strings.Join(segments[:i+1], sep))
+			}
+			node = childMap
+		}
+		last := segments[len(segments)-1]
+		if _, found := node[last]; found {
+			return nil, fmt.Errorf("conflict key %q", key)
+		}
+		node[last] = data[key]
+	}
+	return listify(result).(map[string]interface{}), nil
+}
+
+// listify converts nested maps of index keys to slices.
+func listify(value interface{}) interface{} {
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key, item := range data {
+		data[key] = listify(item)
+	}
+	if list, ok := indexList(data); ok {
+		return list
+	}
+	return data
+}
+
+// indexList converts a map of keys exactly 0 to n-1 to a slice.
+func indexList(data map[string]interface{}) ([]interface{}, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	list := make([]interface{}, len(data))
+	for key, item := range data {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(list) || strconv.Itoa(index) != key {
+			return nil, false
+		}
+		list[index] = item
+	}
+	return list, true
+}
+
+// nestKeys groups flattened keys of a struct source by their first segment,
+// like {"db.host": "x"} to {"db": {"host": "x"}}. Keys of fields are kept unchanged.
+func nestKeys(data map[string]interface{}, sep string, isField func(string) bool) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	nested := make(map[string]map[string]interface{})
+	for key, value := range data {
+		index := strings.Index(key, sep)
+		if index <= 0 || isField(key) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]interface{}, len(data))
+			for k, v := range data {
+				result[k] = v
+			}
+		}
+		delete(result, key)
+		head, rest := key[:index], key[index+len(sep):]
+		child, found := nested[head]
+		if !found {
+			// the source map is copied rather than modified
+			child = make(map[string]interface{})
+			if original := result[head]; original != nil {
+				originalMap, err := toStringMap(reflect.ValueOf(original))
+				if err != nil {
+					return nil, fmt.Errorf("conflict key %q: %q is not a map", key, head)
+				}
+				for k, v := range originalMap {
+					child[k] = v
+				}
+			}
+			nested[head] = child
+			result[head] = child
+		}
+		child[rest] = value
+	}
+	if result == nil {
+		return data, nil
+	}
+	return result, nil
+}
+
+// listFromIndexMap converts a map with flattened index keys like {"0": a, "1.port": 80}
+// to a list for unmarshaling slices and arrays. Indexes must be less than the number of
+// keys, so untrusted input cannot allocate huge sparse lists.
+func listFromIndexMap(src reflect.Value, sep string) (reflect.Value, error) {
+	data, err := toStringMap(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	nested, err := nestKeys(data, sep, func(string) bool { return false })
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	for key := range nested {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return reflect.Value{}, fmt.Errorf("invalid index key: %q", key)
+		} else if index >= len(nested) {
+			return reflect.Value{}, fmt.Errorf("index key out of range: %q", key)
+		}
+	}
+	list := make([]interface{}, len(nested))
+	for key, value := range nested {
+		index, _ := strconv.Atoi(key)
+		list[index] = value
+	}
+	return reflect.ValueOf(list), nil
+}
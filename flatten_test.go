@@ -0,0 +1,139 @@
+package map2struct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	data := map[string]interface{}{
+		"db": map[string]interface{}{
+			"primary": map[string]interface{}{
+				"host": "x",
+			},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 81},
+		},
+		"empty": []interface{}{},
+		"name":  "a",
+	}
+	expect := map[string]interface{}{
+		"db.primary.host": "x",
+		"servers.0.port":  80,
+		"servers.1.port":  81,
+		"empty":           []interface{}{},
+		"name":            "a",
+	}
+	flattened := Flatten(data, ".")
+	if !reflect.DeepEqual(flattened, expect) {
+		t.Errorf("unexpected flatten result: expect=%v, actual=%v", expect, flattened)
+		return
+	}
+	if flattened := Flatten(map[string]interface{}{}, "."); len(flattened) != 0 {
+		t.Error("unexpected flatten result of empty map:", flattened)
+		return
+	}
+	unflattened, err := Unflatten(flattened, ".")
+	if err != nil {
+		t.Error("unflatten fail:", err.Error())
+		return
+	} else if !reflect.DeepEqual(unflattened, data) {
+		t.Errorf("unexpected unflatten result: expect=%v, actual=%v", data, unflattened)
+		return
+	}
+	// sparse indexes are kept as map
+	unflattened, err = Unflatten(map[string]interface{}{"a.1": 1}, ".")
+	if err != nil {
+		t.Error("unflatten fail:", err.Error())
+		return
+	} else if !reflect.DeepEqual(unflattened, map[string]interface{}{"a": map[string]interface{}{"1": 1}}) {
+		t.Error("unexpected unflatten result:", unflattened)
+		return
+	}
+	// conflict
+	if _, err := Unflatten(map[string]interface{}{"a": 1, "a.b": 2}, "."); err == nil {
+		t.Error("unexpected unflatten success")
+		return
+	}
+	if _, err := Unflatten(map[string]interface{}{"a": 1}, ""); err == nil {
+		t.Error("unexpected unflatten success")
+		return
+	}
+}
+
+func TestUnmarshalFlattenedKeys(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int
+	}
+	type TestStruct struct {
+		DB struct {
+			Primary Server
+			Replica Server
+		}
+		Servers []Server
+		Ports   [2]int
+		Hosts   map[string]string
+	}
+	src := map[string]interface{}{
+		"DB.Primary.Host": "x",
+		"DB.Primary.Port": "5432",
+		"DB": map[string]interface{}{
+			"Replica": map[string]interface{}{"Host": "y"},
+		},
+		"Servers.0.Port":    "80",
+		"Servers.1.Host":    "b",
+		"Ports.1":           2,
+		"Ports.0":           1,
+		"Hosts.example.com": "1.2.3.4",
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if a.DB.Primary != (Server{Host: "x", Port: 5432}) || a.DB.Replica.Host != "y" {
+		t.Error("unexpected db:", a.DB)
+		return
+	}
+	if len(a.Servers) != 2 || a.Servers[0].Port != 80 || a.Servers[1].Host != "b" {
+		t.Error("unexpected servers:", a.Servers)
+		return
+	}
+	if a.Ports != [2]int{1, 2} {
+		t.Error("unexpected ports:", a.Ports)
+		return
+	}
+	if a.Hosts["example.com"] != "1.2.3.4" {
+		t.Error("unexpected hosts:", a.Hosts)
+		return
+	}
+	// source is not modified
+	if _, found := src["DB.Primary.Host"]; !found || len(src["DB"].(map[string]interface{})) != 1 {
+		t.Error("unexpected source modification:", src)
+		return
+	}
+	// conflict
+	if err := Unmarshal(&a, map[string]interface{}{"DB": 1, "DB.Primary.Host": "x"}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// invalid index
+	if err := Unmarshal(&a, map[string]interface{}{"Servers.x.Host": "x"}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// disabled
+	decoder := NewDecoder()
+	decoder.KeySeparator = ""
+	var b TestStruct
+	if err := decoder.Unmarshal(&b, map[string]interface{}{"DB.Primary.Host": "x"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b.DB.Primary.Host != "" {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+}
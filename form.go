@@ -0,0 +1,99 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// isFormValues returns whether the type is url.Values or another map[string][]string.
+func isFormValues(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String &&
+		typ.Elem().Kind() == reflect.Slice && typ.Elem().Elem().Kind() == reflect.String
+}
+
+// formData converts form values like url.Values to nested data. Single values are
+// unwrapped to strings and repeated values are lists. Bracketed keys are nested,
+// like "filter[status]" to {"filter": {"status": ...}}, and keys ending with "[]"
+// are always lists, like "ids[]". Values are escaped from interpolation.
+func (decoder *Decoder) formData(src reflect.Value) (map[string]interface{}, error) {
+	keys := make([]string, 0, src.Len())
+	for _, key := range src.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	// sorted for stable conflict errors
+	sort.Strings(keys)
+	data := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		values := src.MapIndex(reflect.ValueOf(key).Convert(src.Type().Key()))
+		segments, list, err := parseFormKey(key)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if values.Len() == 1 && !list {
+			value = decoder.escapeItem(values.Index(0).String())
+		} else {
+			items := make([]interface{}, values.Len())
+			for i := range items {
+				items[i] = decoder.escapeItem(values.Index(i).String())
+			}
+			value = items
+		}
+		if err := setFormValue(data, segments, value); err != nil {
+			return nil, fmt.Errorf("invalid form key %q: %s", key, err.Error())
+		}
+	}
+	return data, nil
+}
+
+// parseFormKey splits a form key like "a[b][c][]" to segments ["a", "b", "c"],
+// and returns whether the key ends with "[]". Keys with unbalanced brackets are not split.
+func parseFormKey(key string) ([]string, bool, error) {
+	start := strings.IndexByte(key, '[')
+	if start <= 0 || !strings.HasSuffix(key, "]") {
+		return []string{key}, false, nil
+	}
+	segments := []string{key[:start]}
+	list := false
+	for rest := key[start:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return []string{key}, false, nil
+		}
+		segment := rest[1:end]
+		rest = rest[end+1:]
+		if segment == "" {
+			if rest != "" {
+				return nil, false, fmt.Errorf("invalid form key %q: \"[]\" must be the last segment", key)
+			}
+			list = true
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return segments, list, nil
+}
+
+// setFormValue sets the value at the segments, creating maps for missing segments.
+func setFormValue(data map[string]interface{}, segments []string, value interface{}) error {
+	for i, segment := range segments[:len(segments)-1] {
+		switch child := data[segment].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			data[segment] = next
+			data = next
+		case map[string]interface{}:
+			data = child
+		default:
+			return fmt.Errorf("conflict with %q", strings.Join(segments[:i+1], "."))
+		}
+	}
+	segment := segments[len(segments)-1]
+	if _, found := data[segment]; found {
+		return fmt.Errorf("conflict with %q", strings.Join(segments, "."))
+	}
+	data[segment] = value
+	return nil
+}
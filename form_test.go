@@ -0,0 +1,117 @@
+package map2struct
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testFormRequest struct {
+	Name   string
+	Page   int
+	Tags   []string
+	IDs    []int
+	Filter map[string]string
+	Sort   struct {
+		Field string
+		Desc  bool
+	}
+	Items []struct {
+		Name string
+	}
+}
+
+func TestUnmarshalForm(t *testing.T) {
+	values, err := url.ParseQuery("Name=app&Page=2&Tags=a&Tags=b&IDs[]=1" +
+		"&Filter[status]=open&Filter[owner]=me&Sort[Field]=date&Sort[Desc]=true" +
+		"&Items[0][Name]=x&Items[1][Name]=y")
+	if err != nil {
+		t.Error("parse query fail:", err.Error())
+		return
+	}
+	var request testFormRequest
+	if err := Unmarshal(&request, values); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := testFormRequest{
+		Name:   "app",
+		Page:   2,
+		Tags:   []string{"a", "b"},
+		IDs:    []int{1},
+		Filter: map[string]string{"status": "open", "owner": "me"},
+		Items:  []struct{ Name string }{{"x"}, {"y"}},
+	}
+	expect.Sort.Field = "date"
+	expect.Sort.Desc = true
+	if !reflect.DeepEqual(request, expect) {
+		t.Errorf("unexpected request: %+v", request)
+		return
+	}
+	// values are not interpolated
+	decoder := NewDecoder()
+	decoder.Resolvers = []Resolver{MapResolver(map[string]string{"X": "y"})}
+	if err := decoder.Unmarshal(&request, map[string][]string{"Name": {"${X}"}}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if request.Name != "${X}" {
+		t.Error("unexpected name:", request.Name)
+		return
+	}
+	// form values are assigned as is
+	var copied url.Values
+	if err := Unmarshal(&copied, values); err != nil || !reflect.DeepEqual(copied, values) {
+		t.Error("unexpected unmarshal result:", copied, err)
+		return
+	}
+	var data interface{}
+	if err := Unmarshal(&data, url.Values{"a[b]": {"1"}}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !reflect.DeepEqual(data, map[string]interface{}{"a": map[string]interface{}{"b": "1"}}) {
+		t.Error("unexpected data:", data)
+		return
+	}
+	cases := map[string]string{
+		"Name=a&Name[x]=b":  `invalid form key "Name[x]": conflict with "Name"`,
+		"Sort[][Field]=a":   `invalid form key "Sort[][Field]"`,
+		"Page=x":            "Page: ",
+		"IDs[50000000]=1":   `IDs: index key out of range: "50000000"`,
+		"IDs.50000000=1":    `IDs: index key out of range: "50000000"`,
+		"IDs[0]=1&IDs[2]=3": `IDs: index key out of range: "2"`,
+	}
+	for query, prefix := range cases {
+		values, _ := url.ParseQuery(query)
+		if err := Unmarshal(&request, values); err == nil || !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("unexpected unmarshal result of %q: %v", query, err)
+			return
+		}
+	}
+}
+
+func TestParseFormKey(t *testing.T) {
+	cases := map[string][]string{
+		"a":          {"a"},
+		"a[b][c]":    {"a", "b", "c"},
+		"a[]":        {"a"},
+		"a[b":        {"a[b"},
+		"[a]":        {"[a]"},
+		"a[b]c]":     {"a[b]c]"},
+		"a[b][c][]":  {"a", "b", "c"},
+		"a[b.c][0]":  {"a", "b.c", "0"},
+		"a[b]x[c]":   {"a[b]x[c]"},
+		"filter[ ]":  {"filter", " "},
+		"a[b][c][d]": {"a", "b", "c", "d"},
+	}
+	for key, expect := range cases {
+		segments, _, err := parseFormKey(key)
+		if err != nil {
+			t.Errorf("parse form key %q fail: %s", key, err.Error())
+			return
+		} else if !reflect.DeepEqual(segments, expect) {
+			t.Errorf("unexpected segments of %q: %q", key, segments)
+			return
+		}
+	}
+}
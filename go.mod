@@ -0,0 +1,8 @@
+module github.com/yangchenxing/go-map2struct
+
+go 1.19
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,190 @@
+package map2struct
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Resolver resolves the values of placeholders like "${DB_HOST}" in string values.
+type Resolver interface {
+	// Resolve returns the value of the name, found is false if the name is unknown.
+	Resolve(name string) (value string, found bool, err error)
+}
+
+// ResolverFunc is an adapter to use a function as Resolver.
+type ResolverFunc func(name string) (string, bool, error)
+
+// Resolve calls the function.
+func (f ResolverFunc) Resolve(name string) (string, bool, error) {
+	return f(name)
+}
+
+// EnvResolver resolves placeholders from environment variables.
+func EnvResolver() Resolver {
+	return ResolverFunc(func(name string) (string, bool, error) {
+		value, found := os.LookupEnv(name)
+		return value, found, nil
+	})
+}
+
+// MapResolver resolves placeholders from a map.
+func MapResolver(values map[string]string) Resolver {
+	return ResolverFunc(func(name string) (string, bool, error) {
+		value, found := values[name]
+		return value, found, nil
+	})
+}
+
+// sourceResolver is replaced with the root source of each unmarshaling.
+type sourceResolver struct{}
+
+func (sourceResolver) Resolve(name string) (string, bool, error) {
+	return "", false, nil
+}
+
+// SourceResolver resolves placeholders from other values of the source being unmarshaled,
+// names are paths of UnmarshalPath like "secrets.db_password".
+func SourceResolver() Resolver {
+	return sourceResolver{}
+}
+
+// withSource returns a copy of the decoder bound to the root source for SourceResolver.
+func (decoder *Decoder) withSource(src reflect.Value) *Decoder {
+	if len(decoder.Resolvers) == 0 {
+		return decoder
+	}
+	bound := *decoder
+	bound.root = src
+	return &bound
+}
+
+// resolve resolves the name by the resolvers in order, source is true for values of the source.
+func (decoder *Decoder) resolve(name string) (value string, found bool, source bool, err error) {
+	for _, resolver := range decoder.Resolvers {
+		_, source = resolver.(sourceResolver)
+		if source {
+			resolver = ResolverFunc(decoder.resolveSource)
+		}
+		if value, found, err = resolver.Resolve(name); err != nil || found {
+			return value, found, source, err
+		}
+	}
+	return "", false, false, nil
+}
+
+func (decoder *Decoder) resolveSource(name string) (string, bool, error) {
+	segments, err := parsePath(name)
+	if err != nil || len(segments) == 0 {
+		return "", false, err
+	}
+	value, _, err := lookupPath(decoder.root, segments)
+	if err != nil {
+		return "", false, nil
+	}
+	if value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if text, ok, err := marshalText(value); ok {
+		return text, true, err
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), true, nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value.Interface()), true, nil
+	}
+	return "", false, fmt.Errorf("placeholder %q: %s", name, badtype("scalar", value))
+}
+
+// interpolate replaces placeholders like "${NAME}" and "${NAME:-default}" in the text,
+// "$$" is replaced with "$". Values of the source resolved by SourceResolver are interpolated
+// too, and values of other resolvers like environment variables and secrets are literal.
+func (decoder *Decoder) interpolate(text string, stack []string) (string, error) {
+	if !strings.Contains(text, "$") {
+		return text, nil
+	}
+	var result strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' || i+1 >= len(text) {
+			result.WriteByte(text[i])
+			continue
+		} else if text[i+1] == '$' {
+			result.WriteByte('$')
+			i++
+			continue
+		} else if text[i+1] != '{' {
+			result.WriteByte('$')
+			continue
+		}
+		end := placeholderEnd(text, i+2)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in text: %s", text)
+		}
+		value, err := decoder.expand(text[i+2:end], stack)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(value)
+		i = end
+	}
+	return result.String(), nil
+}
+
+// placeholderEnd returns the index of the brace closing the placeholder starting at start,
+// nested placeholders in default values are skipped.
+func placeholderEnd(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch {
+		case text[i] == '$' && i+1 < len(text) && text[i+1] == '$':
+			i++
+		case text[i] == '$' && i+1 < len(text) && text[i+1] == '{':
+			depth++
+			i++
+		case text[i] == '}' && depth == 0:
+			return i
+		case text[i] == '}':
+			depth--
+		}
+	}
+	return -1
+}
+
+// expand resolves the content of a placeholder like "NAME:-default".
+func (decoder *Decoder) expand(content string, stack []string) (string, error) {
+	name, defaultValue, hasDefault := content, "", false
+	if index := strings.Index(content, ":-"); index >= 0 {
+		name, defaultValue, hasDefault = content[:index], content[index+2:], true
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty placeholder name: ${%s}", content)
+	}
+	for i, resolving := range stack {
+		if resolving == name {
+			return "", fmt.Errorf("placeholder cycle: %s", strings.Join(append(stack[i:], name), " -> "))
+		}
+	}
+	value, found, source, err := decoder.resolve(name)
+	if err != nil {
+		return "", fmt.Errorf("resolve placeholder %q: %s", name, err.Error())
+	} else if hasDefault && value == "" {
+		return decoder.interpolate(defaultValue, stack)
+	} else if !found {
+		return "", fmt.Errorf("unresolved placeholder: %s", name)
+	} else if !source {
+		return value, nil
+	}
+	return decoder.interpolate(value, append(stack[:len(stack):len(stack)], name))
+}
+
+// escapeItem escapes items split from interpolated text, so they are not interpolated again.
+func (decoder *Decoder) escapeItem(item string) string {
+	if len(decoder.Resolvers) == 0 {
+		return item
+	}
+	return strings.Replace(item, "$", "$$", -1)
+}
@@ -0,0 +1,126 @@
+package map2struct
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	decoder := NewDecoder()
+	decoder.Resolvers = []Resolver{
+		MapResolver(map[string]string{
+			"HOST":   "db",
+			"PORT":   "5432",
+			"ADDR":   "${HOST}:${PORT}",
+			"EMPTY":  "",
+			"DOLLAR": "$$HOME",
+			"A":      "${B}",
+			"B":      "${A}",
+		}),
+		ResolverFunc(func(name string) (string, bool, error) {
+			if name == "FAIL" {
+				return "", false, fmt.Errorf("lookup fail")
+			}
+			return "", false, nil
+		}),
+	}
+	cases := map[string]string{
+		"plain":                 "plain",
+		"${HOST}":               "db",
+		"tcp://${ADDR}/x":       "tcp://${HOST}:${PORT}/x",
+		"${MISSING:-localhost}": "localhost",
+		"${EMPTY:-default}":     "default",
+		"${MISSING:-${HOST}}":   "db",
+		"$${HOST} costs $5 $":   "${HOST} costs $5 $",
+		"${DOLLAR}":             "$$HOME",
+		"${A}":                  "${B}",
+	}
+	for text, expect := range cases {
+		if actual, err := decoder.interpolate(text, nil); err != nil {
+			t.Errorf("interpolate %q fail: %s", text, err.Error())
+			return
+		} else if actual != expect {
+			t.Errorf("unexpected interpolate result of %q: %q", text, actual)
+			return
+		}
+	}
+	errorCases := map[string]string{
+		"${MISSING}": "unresolved",
+		"${HOST":     "unterminated",
+		"${:-x}":     "empty",
+		"${FAIL}":    "lookup fail",
+	}
+	for text, message := range errorCases {
+		if _, err := decoder.interpolate(text, nil); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("unexpected interpolate error of %q: %v", text, err)
+			return
+		}
+	}
+}
+
+func TestUnmarshalInterpolate(t *testing.T) {
+	os.Setenv("MAP2STRUCT_TEST_HOST", "db.local")
+	defer os.Unsetenv("MAP2STRUCT_TEST_HOST")
+	os.Setenv("MAP2STRUCT_TEST_PASSWORD", "a$$b${c")
+	defer os.Unsetenv("MAP2STRUCT_TEST_PASSWORD")
+	type TestStruct struct {
+		Host     string
+		Port     int
+		Timeout  time.Duration
+		Password *string
+		Hosts    []string
+		Labels   map[string]string
+		Literal  string
+		Env      string
+	}
+	decoder := NewDecoder()
+	decoder.Resolvers = []Resolver{EnvResolver(), SourceResolver()}
+	src := map[string]interface{}{
+		"Host":     "${MAP2STRUCT_TEST_HOST:-localhost}",
+		"Port":     "${defaults.port}",
+		"Timeout":  "${MAP2STRUCT_TEST_TIMEOUT:-5s}",
+		"Password": "${secrets.db_password}",
+		"Hosts":    "${Host},${MAP2STRUCT_TEST_MISSING:-a,b}",
+		"Labels":   "env=${defaults.env}",
+		"Literal":  "$${MAP2STRUCT_TEST_HOST}",
+		"Env":      "${MAP2STRUCT_TEST_PASSWORD}",
+		"defaults": map[string]interface{}{"port": 5432, "env": "prod$$"},
+		"secrets":  map[string]interface{}{"db_password": "p$$w"},
+	}
+	var a TestStruct
+	if err := decoder.Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if a.Host != "db.local" || a.Port != 5432 || a.Timeout != 5*time.Second ||
+		a.Password == nil || *a.Password != "p$w" || a.Literal != "${MAP2STRUCT_TEST_HOST}" || a.Env != "a$$b${c" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if len(a.Hosts) != 3 || a.Hosts[0] != "db.local" || a.Hosts[2] != "b" {
+		t.Error("unexpected hosts:", a.Hosts)
+		return
+	}
+	if a.Labels["env"] != "prod$" {
+		t.Error("unexpected labels:", a.Labels)
+		return
+	}
+	// cycle between keys
+	src = map[string]interface{}{"Host": "${Literal}", "Literal": "${Host}"}
+	if err := decoder.Unmarshal(&a, src); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+	// interpolation is disabled by default
+	var b TestStruct
+	if err := Unmarshal(&b, map[string]interface{}{"Host": "${HOST}"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b.Host != "${HOST}" {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+}
@@ -0,0 +1,244 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Delete is the value deleting the key from lower layers in Merge, like `password: $delete` in a file.
+const Delete = "$delete"
+
+// Merge deep merges the layers, values of later layers take precedence.
+// Maps are merged recursively, and keys of the Delete value are deleted.
+// Flat keys like "DB.Host" of properties and environment layers are nested by the
+// KeySeparator before merging, so they take precedence by their layers.
+// Slices are merged by the SliceMerge strategy of the default decoder, see Decoder.Merge.
+// The layers are not modified.
+func Merge(layers ...map[string]interface{}) (map[string]interface{}, error) {
+	return defaultDecoder.Merge(layers...)
+}
+
+// Merge deep merges the layers with the SliceMerge and MapMerge strategies of the decoder:
+// MergeReplace replaces lower values, MergeAppend appends slices, MergeTruncate and
+// MergeMerge merge slices by indexes, and MergeByKey merges slices of maps by the key.
+func (decoder *Decoder) Merge(layers ...map[string]interface{}) (map[string]interface{}, error) {
+	data, _, err := decoder.mergeLayers(nil, layers)
+	return data, err
+}
+
+// UnmarshalLayers merges the layers and unmarshals the result to the destination,
+// see Merge. The `merge` tags of fields override the strategies of the decoder.
+// It returns the index of the layer supplying each value, by paths like
+// "Servers[0].Port" of errors. Values of slices and maps are indexed by elements.
+func UnmarshalLayers(dest interface{}, layers ...map[string]interface{}) (map[string]int, error) {
+	return defaultDecoder.UnmarshalLayers(dest, layers...)
+}
+
+// UnmarshalLayers merges the layers and unmarshals the result with the options of the decoder.
+func (decoder *Decoder) UnmarshalLayers(dest interface{}, layers ...map[string]interface{}) (map[string]int, error) {
+	if value := reflect.ValueOf(dest); value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil, fmt.Errorf("invalid destination: expect non-nil pointer but found %T", dest)
+	}
+	typ := reflect.TypeOf(dest).Elem()
+	data, origin, err := decoder.mergeLayers(typ, layers)
+	if err != nil {
+		return nil, err
+	}
+	origins := make(map[string]int)
+	layerOrigins(origins, "", typ, origin)
+	return origins, decoder.Unmarshal(dest, data)
+}
+
+// mergeLayers merges the layers for the type, the type can be nil for data without types.
+// It returns the merged data and the tree of layer indexes of its values.
+func (decoder *Decoder) mergeLayers(typ reflect.Type, layers []map[string]interface{}) (map[string]interface{}, interface{}, error) {
+	var data, origin interface{} = map[string]interface{}{}, map[string]interface{}{}
+	for i, layer := range layers {
+		var err error
+		if data, origin, err = decoder.mergeLayerValue(typ, "", data, origin, layer, i); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data.(map[string]interface{}), origin, nil
+}
+
+// mergeLayerValue merges the value of a layer over the base value. The origin is the tree
+// of layer indexes of the base value, which is int for leaf values, map[string]interface{}
+// for maps and []interface{} for slices.
+func (decoder *Decoder) mergeLayerValue(typ reflect.Type, tag reflect.StructTag, base, origin, value interface{}, layer int) (interface{}, interface{}, error) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	src := reflect.ValueOf(value)
+	if src.Kind() == reflect.Map && typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && decoder.KeySeparator != "" {
+		list, err := listFromIndexMap(src, decoder.KeySeparator)
+		if err != nil {
+			return nil, nil, err
+		}
+		src = list
+	}
+	switch src.Kind() {
+	case reflect.Map:
+		data, err := decoder.layerData(typ, src)
+		if err != nil {
+			return nil, nil, err
+		}
+		baseData, isMap := base.(map[string]interface{})
+		baseOrigin, _ := origin.(map[string]interface{})
+		if !isMap || typ != nil && typ.Kind() == reflect.Map && mergeStrategy(tag, decoder.MapMerge, typ) == MergeReplace {
+			baseData, baseOrigin = nil, nil
+		}
+		result := make(map[string]interface{}, len(baseData)+len(data))
+		resultOrigin := make(map[string]interface{}, len(baseData)+len(data))
+		for key, item := range baseData {
+			result[key], resultOrigin[key] = item, baseOrigin[key]
+		}
+		for key, item := range data {
+			if item == Delete {
+				delete(result, key)
+				delete(resultOrigin, key)
+				continue
+			}
+			itemType, itemTag, segment := layerField(typ, key)
+			if typ != nil && typ.Kind() == reflect.Map {
+				// values of maps are merged by the tag like unmarshaling
+				itemTag = tag
+			}
+			if result[key], resultOrigin[key], err = decoder.mergeLayerValue(itemType, itemTag, result[key], resultOrigin[key], item, layer); err != nil {
+				return nil, nil, pathError(segment, err)
+			}
+		}
+		return result, resultOrigin, nil
+	case reflect.Slice:
+		if src.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		baseList, _ := base.([]interface{})
+		baseOrigin, _ := origin.([]interface{})
+		return decoder.mergeLayerSlice(elemType, mergeStrategy(tag, decoder.SliceMerge, typ), baseList, baseOrigin, src, layer)
+	}
+	return value, layer, nil
+}
+
+// layerData converts the map of a layer to string keys, and nests the flat keys for
+// structs and data without types like unmarshaling structs.
+func (decoder *Decoder) layerData(typ reflect.Type, src reflect.Value) (map[string]interface{}, error) {
+	data, err := toStringMap(src)
+	if err != nil || decoder.KeySeparator == "" || typ != nil && typ.Kind() != reflect.Struct {
+		return data, err
+	}
+	return nestKeys(data, decoder.KeySeparator, func(name string) bool {
+		if typ == nil {
+			return false
+		}
+		_, found := typ.FieldByName(name)
+		return found
+	})
+}
+
+// mergeLayerSlice merges the elements of a layer over the base list by the strategy.
+func (decoder *Decoder) mergeLayerSlice(elemType reflect.Type, strategy MergeStrategy, base, origin []interface{}, src reflect.Value, layer int) (interface{}, interface{}, error) {
+	offset := 0
+	switch strategy {
+	case MergeReplace:
+		base, origin = nil, nil
+	case MergeAppend:
+		offset = len(base)
+	case MergeTruncate, "", MergeMerge:
+	default:
+		if key := strategy.key(); key != "" {
+			return decoder.mergeLayerSliceByKey(elemType, key, base, origin, src, layer)
+		}
+		return nil, nil, fmt.Errorf("unknown slice merge strategy: %q", strategy)
+	}
+	length := offset + src.Len()
+	if strategy == MergeMerge && len(base) > length {
+		length = len(base)
+	}
+	result := make([]interface{}, length)
+	resultOrigin := make([]interface{}, length)
+	copy(result, base)
+	copy(resultOrigin, origin)
+	for i := 0; i < src.Len(); i++ {
+		j := offset + i
+		var err error
+		if result[j], resultOrigin[j], err = decoder.mergeLayerValue(elemType, "", result[j], resultOrigin[j], src.Index(i).Interface(), layer); err != nil {
+			return nil, nil, pathError(indexSegment(i), err)
+		}
+	}
+	return result, resultOrigin, nil
+}
+
+// mergeLayerSliceByKey merges the maps of a layer over the base maps with the same value of the key,
+// or appends them if no base map has the key.
+func (decoder *Decoder) mergeLayerSliceByKey(elemType reflect.Type, key string, base, origin []interface{}, src reflect.Value, layer int) (interface{}, interface{}, error) {
+	result := append([]interface{}{}, base...)
+	resultOrigin := append([]interface{}{}, origin...)
+	for i := 0; i < src.Len(); i++ {
+		item := src.Index(i).Interface()
+		index := len(result)
+		if data, err := toStringMap(reflect.ValueOf(item)); err == nil {
+			for j, baseItem := range result {
+				if baseData, ok := baseItem.(map[string]interface{}); ok && reflect.DeepEqual(baseData[key], data[key]) {
+					index = j
+					break
+				}
+			}
+		}
+		if index == len(result) {
+			result, resultOrigin = append(result, nil), append(resultOrigin, nil)
+		}
+		var err error
+		if result[index], resultOrigin[index], err = decoder.mergeLayerValue(elemType, "", result[index], resultOrigin[index], item, layer); err != nil {
+			return nil, nil, pathError(indexSegment(i), err)
+		}
+	}
+	return result, resultOrigin, nil
+}
+
+// layerField returns the type, tag and path segment of the key of data for the type.
+func layerField(typ reflect.Type, key string) (reflect.Type, reflect.StructTag, string) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return nil, "", keySegment(key)
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if field, found := typ.FieldByName(key); found && field.PkgPath == "" {
+			return field.Type, field.Tag, key
+		}
+	case reflect.Map:
+		return typ.Elem(), "", keySegment(key)
+	}
+	return nil, "", keySegment(key)
+}
+
+// layerOrigins collects the layer indexes of the origin tree by paths.
+func layerOrigins(origins map[string]int, path string, typ reflect.Type, origin interface{}) {
+	switch origin := origin.(type) {
+	case int:
+		origins[path] = origin
+	case map[string]interface{}:
+		for key, child := range origin {
+			childType, _, segment := layerField(typ, key)
+			layerOrigins(origins, joinPath(path, segment), childType, child)
+		}
+	case []interface{}:
+		for typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		for i, child := range origin {
+			layerOrigins(origins, joinPath(path, indexSegment(i)), elemType, child)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package map2struct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	defaults := map[string]interface{}{
+		"name": "app",
+		"db":   map[string]interface{}{"host": "localhost", "port": 5432, "password": "x"},
+		"tags": []interface{}{"a", "b"},
+	}
+	file := map[string]interface{}{
+		"db":   map[interface{}]interface{}{"host": "db.example.com", "password": Delete},
+		"tags": []interface{}{"c"},
+		"new":  map[string]interface{}{"a": Delete, "b": 1},
+	}
+	data, err := Merge(defaults, file)
+	if err != nil {
+		t.Error("merge fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"name": "app",
+		"db":   map[string]interface{}{"host": "db.example.com", "port": 5432},
+		"tags": []interface{}{"c"},
+		"new":  map[string]interface{}{"b": 1},
+	}
+	if !reflect.DeepEqual(data, expect) {
+		t.Error("unexpected merged data:", data)
+		return
+	} else if len(defaults["db"].(map[string]interface{})) != 3 {
+		t.Error("unexpected modified layer:", defaults)
+		return
+	}
+	cases := map[MergeStrategy][]interface{}{
+		MergeReplace:      {"c"},
+		MergeAppend:       {"a", "b", "c"},
+		MergeTruncate:     {"c"},
+		MergeMerge:        {"c", "b"},
+		MergeByKey("key"): {"a", "b", "c"},
+	}
+	for strategy, expect := range cases {
+		decoder := NewDecoder()
+		decoder.SliceMerge = strategy
+		if data, err := decoder.Merge(defaults, file); err != nil {
+			t.Errorf("merge with %q fail: %s", strategy, err.Error())
+			return
+		} else if !reflect.DeepEqual(data["tags"], expect) {
+			t.Errorf("unexpected merged slice with %q: %v", strategy, data["tags"])
+			return
+		}
+	}
+	decoder := NewDecoder()
+	decoder.SliceMerge = "unknown"
+	if _, err := decoder.Merge(defaults, file); err == nil || err.Error() != `[tags]: unknown slice merge strategy: "unknown"` {
+		t.Error("unexpected merge result:", err)
+		return
+	}
+}
+
+type testLayerServer struct {
+	Name string
+	Port int
+}
+
+type testLayerConfig struct {
+	Name    string
+	Labels  map[string]string `merge:"replace"`
+	Servers []testLayerServer `merge:"key:Name"`
+	Ports   []int             `merge:"append"`
+}
+
+func TestUnmarshalLayers(t *testing.T) {
+	defaults := map[string]interface{}{
+		"Name":    "app",
+		"Labels":  map[string]interface{}{"a": "1"},
+		"Servers": []interface{}{map[string]interface{}{"Name": "a", "Port": 80}},
+		"Ports":   []interface{}{80},
+	}
+	file := map[string]interface{}{
+		"Labels": map[string]interface{}{"b": "2"},
+		"Servers": []interface{}{
+			map[string]interface{}{"Name": "b", "Port": 81},
+			map[string]interface{}{"Name": "a", "Port": 8080},
+		},
+		"Ports": []interface{}{443},
+	}
+	flags := map[string]interface{}{"Name": "flag"}
+	var config testLayerConfig
+	origins, err := UnmarshalLayers(&config, defaults, file, flags)
+	if err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := testLayerConfig{
+		Name:    "flag",
+		Labels:  map[string]string{"b": "2"},
+		Servers: []testLayerServer{{"a", 8080}, {"b", 81}},
+		Ports:   []int{80, 443},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("unexpected config: %+v", config)
+		return
+	}
+	expectOrigins := map[string]int{
+		"Name":            2,
+		"Labels[b]":       1,
+		"Servers[0].Name": 1,
+		"Servers[0].Port": 1,
+		"Servers[1].Name": 1,
+		"Servers[1].Port": 1,
+		"Ports[0]":        0,
+		"Ports[1]":        1,
+	}
+	if !reflect.DeepEqual(origins, expectOrigins) {
+		t.Error("unexpected origins:", origins)
+		return
+	}
+	if _, err := UnmarshalLayers(&config, map[string]interface{}{"Ports": "x"}); err == nil || err.Error()[:8] != "Ports[0]" {
+		t.Error("unexpected unmarshal result:", err)
+		return
+	}
+	// flat keys are nested before merging, so layers of both forms take precedence by order
+	var mixed struct {
+		DB struct {
+			Host string
+			Port int
+		}
+		Ports []int
+	}
+	properties := map[string]interface{}{"DB.Host": "from-properties", "DB.Port": "5432", "Ports.0": "80"}
+	nested := map[string]interface{}{"DB": map[string]interface{}{"Host": "from-file"}, "Ports": []interface{}{443}}
+	origins, err = UnmarshalLayers(&mixed, properties, nested)
+	if err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if mixed.DB.Host != "from-file" || mixed.DB.Port != 5432 || !reflect.DeepEqual(mixed.Ports, []int{443}) {
+		t.Errorf("unexpected config: %+v", mixed)
+		return
+	} else if expect := map[string]int{"DB.Host": 1, "DB.Port": 0, "Ports[0]": 1}; !reflect.DeepEqual(origins, expect) {
+		t.Error("unexpected origins:", origins)
+		return
+	}
+	if data, err := Merge(nested, properties); err != nil {
+		t.Error("merge fail:", err.Error())
+		return
+	} else if host := data["DB"].(map[string]interface{})["Host"]; host != "from-properties" {
+		t.Error("unexpected merged host:", host)
+		return
+	}
+}
@@ -5,92 +5,105 @@ import (
 	"fmt"
 	"math"
 	"reflect"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 var (
-	timeType     = reflect.TypeOf(time.Time{})
-	durationType = reflect.TypeOf(time.Second)
-
-	percentageFloatPattern = regexp.MustCompile("^[0-9]+(\\.[0-9]+)?%$")
-
-	timeLayouts = []string{
-		"2006-01-02:15:04:05",
-		"2006-01-02:15:04:05-0700",
-		time.ANSIC,       // "Mon Jan _2 15:04:05 2006"
-		time.UnixDate,    // "Mon Jan _2 15:04:05 MST 2006"
-		time.RubyDate,    // "Mon Jan 02 15:04:05 -0700 2006"
-		time.RFC822,      // "02 Jan 06 15:04 MST"
-		time.RFC822Z,     // "02 Jan 06 15:04 -0700"
-		time.RFC850,      // "Monday, 02-Jan-06 15:04:05 MST"
-		time.RFC1123,     // "Mon, 02 Jan 2006 15:04:05 MST"
-		time.RFC1123Z,    // "Mon, 02 Jan 2006 15:04:05 -0700"
-		time.RFC3339,     // "2006-01-02T15:04:05Z07:00"
-		time.RFC3339Nano, // "2006-01-02T15:04:05.999999999Z07:00"
-		time.Kitchen,     // "3:04PM"
-		time.Stamp,       // "Jan _2 15:04:05"
-		time.StampMilli,  // "Jan _2 15:04:05.000"
-		time.StampMicro,  // "Jan _2 15:04:05.000000"
-		time.StampNano,   // "Jan _2 15:04:05.000000000"
-	}
-
 	valueTrue  = reflect.ValueOf(true)
 	valueFalse = reflect.ValueOf(false)
 )
 
-// Unmarshal unmarshal map[string]interface{} to a struct instance
-func Unmarshal(dest, src interface{}) error {
-	return unmarshal(rvalue(dest), reflect.ValueOf(src))
-}
-
-func unmarshal(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshal(dest, src reflect.Value, tag reflect.StructTag) (err error) {
+	// reflection panics are reported as errors of the innermost value
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unmarshal %s panic: %v", dest.Type(), r)
+		}
+	}()
 	if src.Kind() == reflect.Interface {
 		src = src.Elem()
 	}
+	if isNil(src) {
+		return decoder.unmarshalNil(dest)
+	}
+	// source pointers are dereferenced unless assigned to pointers or interfaces
+	if src.Kind() == reflect.Ptr && dest.Kind() != reflect.Ptr && dest.Kind() != reflect.Interface {
+		src = src.Elem()
+	}
+	// pointers are skipped, so text is interpolated only once
+	if src.Kind() == reflect.String && dest.Kind() != reflect.Ptr && len(decoder.Resolvers) > 0 {
+		text, err := decoder.interpolate(src.String(), nil)
+		if err != nil {
+			return err
+		}
+		src = reflect.ValueOf(text).Convert(src.Type())
+	}
+	if src.Kind() == reflect.String && dest.Kind() != reflect.Ptr && decoder.ResolveSecrets && !decoder.literal {
+		if resolved, err := decoder.unmarshalSecretRef(dest, src, tag); resolved {
+			return err
+		}
+	}
 	switch dest.Type() {
 	case timeType:
-		return unmarshalTime(dest, src)
+		return decoder.unmarshalTime(dest, src, tag)
 	case durationType:
-		return unmarshalDuration(dest, src)
+		return decoder.unmarshalDuration(dest, src, tag)
 	}
-	if dest.CanAddr() {
+	if converter := converters[dest.Type()]; converter != nil {
+		return decoder.unmarshalConverter(converter, dest, src)
+	}
+	if dest.CanAddr() && dest.Addr().CanInterface() {
 		if textUnmarshaler, ok := dest.Addr().Interface().(encoding.TextUnmarshaler); ok {
 			return unmarshalText(textUnmarshaler, src)
 		}
 	}
-	var unmarshalMethod func(reflect.Value, reflect.Value) error
+	var unmarshalMethod func(reflect.Value, reflect.Value, reflect.StructTag) error
 	switch dest.Kind() {
 	case reflect.Bool:
-		unmarshalMethod = unmarshalBool
+		unmarshalMethod = decoder.unmarshalBool
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		unmarshalMethod = unmarshalInt
+		unmarshalMethod = decoder.unmarshalInt
 	case reflect.Float32, reflect.Float64:
-		unmarshalMethod = unmarshalFloat
+		unmarshalMethod = decoder.unmarshalFloat
 	case reflect.Array:
-		unmarshalMethod = unmarshalArray
+		unmarshalMethod = decoder.unmarshalArray
 	case reflect.Map:
-		unmarshalMethod = unmarshalMap
+		unmarshalMethod = decoder.unmarshalMap
 	case reflect.Slice:
-		unmarshalMethod = unmarshalSlice
+		unmarshalMethod = decoder.unmarshalSlice
 	case reflect.String:
-		unmarshalMethod = unmarshalString
+		unmarshalMethod = decoder.unmarshalString
 	case reflect.Struct:
-		unmarshalMethod = unmarshalStruct
+		unmarshalMethod = decoder.unmarshalStruct
 	case reflect.Ptr:
-		unmarshalMethod = unmarshalPtr
+		unmarshalMethod = decoder.unmarshalPtr
 	case reflect.Interface:
-		unmarshalMethod = unmarshalInterface
+		unmarshalMethod = decoder.unmarshalInterface
 	}
 	if unmarshalMethod == nil {
 		return fmt.Errorf("unsupported kind: %s", dest.Kind())
 	}
-	return unmarshalMethod(dest, src)
+	return unmarshalMethod(dest, src, tag)
 }
 
-func unmarshalBool(dest, src reflect.Value) error {
+// unmarshalNil unmarshal nil source value according to the NilMode of the decoder.
+func (decoder *Decoder) unmarshalNil(dest reflect.Value) error {
+	if !isSupportedKind(dest.Kind()) {
+		return fmt.Errorf("unsupported kind: %s", dest.Kind())
+	}
+	switch decoder.NilMode {
+	case NilIgnore:
+		return nil
+	case NilError:
+		return fmt.Errorf("unexpected nil for %s", dest.Type())
+	}
+	dest.Set(reflect.Zero(dest.Type()))
+	return nil
+}
+
+func (decoder *Decoder) unmarshalBool(dest, src reflect.Value, tag reflect.StructTag) error {
 	switch src.Kind() {
 	case reflect.Bool:
 		dest.SetBool(src.Bool())
@@ -106,9 +119,14 @@ func unmarshalBool(dest, src reflect.Value) error {
 	return badtype("bool/string", src)
 }
 
-func unmarshalInt(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalInt(dest, src reflect.Value, tag reflect.StructTag) error {
 	srcKind := src.Kind()
 	destKind := dest.Kind()
+	if enum := enums[dest.Type()]; enum != nil && srcKind == reflect.String {
+		return enum.unmarshal(dest, src.String())
+	} else if enum != nil && enum.flags && (srcKind == reflect.Slice || srcKind == reflect.Array) {
+		return enum.unmarshalFlags(dest, src)
+	}
 	if destKind >= reflect.Int && destKind <= reflect.Int64 {
 		switch {
 		case srcKind >= reflect.Int && srcKind <= reflect.Int64:
@@ -147,7 +165,7 @@ func unmarshalInt(dest, src reflect.Value) error {
 	return nil
 }
 
-func unmarshalFloat(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalFloat(dest, src reflect.Value, tag reflect.StructTag) error {
 	srcKind := src.Kind()
 	switch {
 	case srcKind >= reflect.Int && srcKind <= reflect.Int64:
@@ -157,42 +175,89 @@ func unmarshalFloat(dest, src reflect.Value) error {
 	case srcKind == reflect.Float32 || srcKind == reflect.Float64:
 		dest.SetFloat(src.Float())
 	case srcKind == reflect.String:
-		text := src.String()
-		if text == "Inf" || text == "+Inf" {
-			dest.SetFloat(math.Inf(1))
-		} else if text == "-Inf" {
-			dest.SetFloat(math.Inf(-1))
-		} else if text == "NaN" {
-			dest.SetFloat(math.NaN())
-		} else if percentageFloatPattern.MatchString(text) {
-			floatValue, _ := strconv.ParseFloat(text[:len(text)-1], 64)
-			dest.SetFloat(floatValue / 100)
-		} else {
-			floatValue, err := strconv.ParseFloat(text, 64)
-			if err != nil {
-				return err
-			}
-			dest.SetFloat(floatValue)
+		floatValue, err := decoder.parseFloat(src.String())
+		if err != nil {
+			return err
 		}
+		dest.SetFloat(floatValue)
 	default:
 		return badtype("int/float/string", src)
 	}
 	return nil
 }
 
-func unmarshalArray(dest, src reflect.Value) error {
+// parseFloat parses float text with special values and scale suffixes of the decoder.
+func (decoder *Decoder) parseFloat(text string) (float64, error) {
+	if floatValue, found := decoder.FloatValues[text]; found {
+		return floatValue, nil
+	}
+	for _, suffix := range decoder.floatSuffixes() {
+		if strings.HasSuffix(text, suffix) {
+			floatValue, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(text, suffix)), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid float text: %s", text)
+			}
+			return floatValue / decoder.FloatSuffixes[suffix], nil
+		}
+	}
+	floatValue, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	} else if math.IsInf(floatValue, 0) || math.IsNaN(floatValue) {
+		// special values must be defined in FloatValues
+		return 0, fmt.Errorf("invalid float text: %s", text)
+	}
+	return floatValue, nil
+}
+
+// floatSuffixes returns the float suffixes, longer suffixes first.
+func (decoder *Decoder) floatSuffixes() []string {
+	suffixes := make([]string, 0, len(decoder.FloatSuffixes))
+	for suffix := range decoder.FloatSuffixes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		if len(suffixes[i]) != len(suffixes[j]) {
+			return len(suffixes[i]) > len(suffixes[j])
+		}
+		return suffixes[i] < suffixes[j]
+	})
+	return suffixes
+}
+
+func (decoder *Decoder) unmarshalArray(dest, src reflect.Value, tag reflect.StructTag) error {
+	if src.Kind() == reflect.String {
+		list, err := decoder.splitList(src.String(), tag)
+		if err != nil {
+			return err
+		}
+		src = list
+	} else if src.Kind() == reflect.Map && decoder.KeySeparator != "" {
+		list, err := listFromIndexMap(src, decoder.KeySeparator)
+		if err != nil {
+			return err
+		}
+		src = list
+	}
 	srcKind := src.Kind()
 	if srcKind != reflect.Slice && srcKind != reflect.Array {
 		return badtype("array/slice", src)
 	} else if src.Len() != dest.Len() {
 		return fmt.Errorf("array length mismatch: %d vs. %d", src.Len(), dest.Len())
 	}
-	return copySlice(dest, src)
+	return decoder.copySlice(dest, src, 0, tag)
 }
 
-func unmarshalInterface(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalInterface(dest, src reflect.Value, tag reflect.StructTag) error {
 	// interface{}
-	if dest.Type().NumMethod() == 0 {
+	if dest.Type().NumMethod() == 0 && decoder.patching {
+		if patched := MergePatch(dest.Interface(), src.Interface()); patched != nil {
+			dest.Set(reflect.ValueOf(patched))
+		} else {
+			dest.Set(reflect.Zero(dest.Type()))
+		}
+		return nil
+	} else if dest.Type().NumMethod() == 0 {
 		dest.Set(src)
 		return nil
 	}
@@ -207,129 +272,223 @@ func unmarshalInterface(dest, src reflect.Value) error {
 		return nil
 	}
 	// 非直接赋值情况
-	if data, ok := src.Interface().(map[string]interface{}); !ok {
-		return badtype("map[string]interface{}", src)
-	} else if instance, err := createByFactory(dest.Type(), data); err != nil {
+	if data, err := toStringMap(src); err != nil {
+		return err
+	} else if instance, err := decoder.createByFactory(dest.Type(), data); err != nil {
 		return err
+	} else if value := reflect.ValueOf(instance); !value.IsValid() || !value.Type().AssignableTo(dest.Type()) {
+		return fmt.Errorf("factory of %s creates %T", dest.Type(), instance)
 	} else {
-		dest.Set(reflect.ValueOf(instance))
+		dest.Set(value)
+		decoder.recordOrigin(factoryOrigin(instance))
 	}
 	return nil
 }
 
-func unmarshalMap(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalMap(dest, src reflect.Value, tag reflect.StructTag) error {
+	if src.Kind() == reflect.String {
+		data, err := decoder.splitMap(src.String(), tag)
+		if err != nil {
+			return err
+		} else if data.IsValid() {
+			src = data
+		} else if dest.Type().Elem().Kind() == reflect.Bool {
+			return decoder.unmarshalSet(dest, src, tag)
+		}
+	}
 	if src.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.Bool {
-		return unmarshalSet(dest, src)
+		return decoder.unmarshalSet(dest, src, tag)
+	} else if src.Kind() == reflect.Struct {
+		data, err := toStringMap(src)
+		if err != nil {
+			return err
+		}
+		src = reflect.ValueOf(data)
 	}
 	if src.Kind() != reflect.Map {
 		return badtype("map", src)
 	}
-	if dest.IsNil() {
-		dest.Set(reflect.MakeMap(dest.Type()))
+	strategy := decoder.strategy(tag, decoder.MapMerge, dest.Type())
+	if err := decoder.prepareMap(dest, strategy); err != nil {
+		return err
 	}
 	keyType := dest.Type().Key()
 	valueType := dest.Type().Elem()
 	for _, srcKey := range src.MapKeys() {
 		destKey := reflect.New(keyType).Elem()
-		if err := unmarshal(destKey, srcKey); err != nil {
-			return fmt.Errorf("unmarshal map index [%s] key error: %s",
-				srcKey.Interface(), err.Error())
+		if err := decoder.unmarshal(destKey, srcKey, tag); err != nil {
+			return pathError(keySegment(srcKey.Interface()), fmt.Errorf("key error: %s", err.Error()))
+		}
+		if decoder.patching && isNil(src.MapIndex(srcKey)) {
+			// null deletes the key in patches
+			dest.SetMapIndex(destKey, reflect.Value{})
+			continue
 		}
 		destValue := reflect.New(valueType).Elem()
-		if err := unmarshal(destValue, src.MapIndex(srcKey)); err != nil {
-			return fmt.Errorf("unmarshal map index [%s] value error: %s",
-				srcKey.Interface(), err.Error())
+		if existing := dest.MapIndex(destKey); existing.IsValid() && strategy != MergeReplace {
+			destValue.Set(existing)
+		}
+		if err := decoder.at(keySegment(srcKey.Interface())).unmarshal(destValue, src.MapIndex(srcKey), tag); err != nil {
+			return pathError(keySegment(srcKey.Interface()), err)
 		}
 		dest.SetMapIndex(destKey, destValue)
 	}
 	return nil
 }
 
-func unmarshalSet(dest, src reflect.Value) error {
-	if dest.IsNil() {
-		dest.Set(reflect.MakeMap(dest.Type()))
+func (decoder *Decoder) unmarshalSet(dest, src reflect.Value, tag reflect.StructTag) error {
+	if src.Kind() == reflect.String {
+		list, err := decoder.splitList(src.String(), tag)
+		if err != nil {
+			return err
+		}
+		src = list
+	}
+	strategy := decoder.strategy(tag, decoder.MapMerge, dest.Type())
+	if decoder.patching {
+		// sets are lists in patches, which are replaced
+		strategy = MergeReplace
+	}
+	if err := decoder.prepareMap(dest, strategy); err != nil {
+		return err
 	}
 	keyType := dest.Type().Key()
 	for i := 0; i < src.Len(); i++ {
 		destKey := reflect.New(keyType).Elem()
-		if err := unmarshal(destKey, src.Index(i)); err != nil {
-			return fmt.Errorf("unmarshal set item [%d] error: %s", i, err.Error())
+		if err := decoder.unmarshal(destKey, src.Index(i), tag); err != nil {
+			return pathError(indexSegment(i), err)
 		}
 		dest.SetMapIndex(destKey, valueTrue)
 	}
 	return nil
 }
 
-func unmarshalSlice(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalSlice(dest, src reflect.Value, tag reflect.StructTag) error {
 	srcKind := src.Kind()
+	if srcKind == reflect.String && dest.Type().Elem().Kind() == reflect.Uint8 {
+		dest.SetBytes([]byte(src.String()))
+		return nil
+	} else if srcKind == reflect.String {
+		list, err := decoder.splitList(src.String(), tag)
+		if err != nil {
+			return err
+		}
+		src, srcKind = list, list.Kind()
+	} else if srcKind == reflect.Map && decoder.KeySeparator != "" {
+		list, err := listFromIndexMap(src, decoder.KeySeparator)
+		if err != nil {
+			return err
+		}
+		src, srcKind = list, list.Kind()
+	}
 	if srcKind != reflect.Slice && srcKind != reflect.Array {
 		return badtype("array/slice", src)
 	}
-	if dest.Len() < src.Len() {
-		dest.Set(reflect.MakeSlice(dest.Type(), src.Len(), src.Len()))
+	strategy := decoder.strategy(tag, decoder.SliceMerge, dest.Type())
+	if key := strategy.key(); key != "" {
+		return decoder.mergeSliceByKey(dest, src, key, tag)
 	}
-	return copySlice(dest, src)
+	elems, err := decoder.prepareSlice(dest, src.Len(), strategy)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if strategy == MergeAppend {
+		offset = dest.Len() - elems.Len()
+	}
+	return decoder.copySlice(elems, src, offset, tag)
 }
 
-func unmarshalString(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalString(dest, src reflect.Value, tag reflect.StructTag) error {
+	if enum := enums[src.Type()]; enum != nil && src.Kind() != reflect.String {
+		if name, found := enum.Name(src.Interface()); found {
+			src = reflect.ValueOf(name)
+		}
+	}
 	if src.Kind() != reflect.String {
-		return badtype("string", src)
+		// values marshaled to text, like time.Time and net.IP
+		text, ok, err := marshalText(src)
+		if !ok {
+			return badtype("string", src)
+		} else if err != nil {
+			return err
+		}
+		src = reflect.ValueOf(text)
+	}
+	if enum := enums[dest.Type()]; enum != nil {
+		return enum.unmarshal(dest, src.String())
 	}
 	dest.SetString(src.String())
 	return nil
 }
 
-func unmarshalStruct(dest, src reflect.Value) error {
+func (decoder *Decoder) unmarshalStruct(dest, src reflect.Value, tag reflect.StructTag) error {
 	if dest.Type() == src.Type() {
 		dest.Set(src)
 		return nil
 	}
-	data, ok := src.Interface().(map[string]interface{})
-	if !ok {
-		return badtype("map[string]interface{}", src)
+	data, err := toStringMap(src)
+	if err != nil {
+		return err
 	}
-
 	typ := dest.Type()
+	if decoder.KeySeparator != "" {
+		isField := func(name string) bool {
+			_, found := typ.FieldByName(name)
+			return found
+		}
+		if data, err = nestKeys(data, decoder.KeySeparator, isField); err != nil {
+			return err
+		}
+		src = reflect.ValueOf(data)
+	}
+
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
-		if field.Anonymous {
-			if err := unmarshal(dest.Field(i), src); err != nil {
-				return fmt.Errorf("unmarshal anonymous field %q fail: type=%q, error=%q",
-					field.Name, typ, err.Error())
+		if field.Anonymous && (field.PkgPath == "" || field.Type.Kind() == reflect.Struct) {
+			// fields of anonymous field are promoted, so the path is unchanged
+			if err := decoder.unmarshal(dest.Field(i), src, field.Tag); err != nil {
+				return err
 			}
 			continue
+		} else if field.PkgPath != "" {
+			// unexported
+			continue
 		}
+		origin := Origin{Kind: OriginSource}
 		value, found := data[field.Name]
-		if !found {
+		if text, hasDefault := field.Tag.Lookup("default"); !found && hasDefault && !decoder.patching && dest.Field(i).IsZero() {
+			// defaults are used for zero fields, so values of earlier sources are kept,
+			// and never by patches leaving absent keys untouched
+			value, origin = text, defaultOrigin(field, text)
+		} else if !found && !decoder.patching && hasDefaults(field.Type) {
+			// defaults of nested fields are used without the source value
+			value, origin = map[string]interface{}{}, Origin{}
+		} else if !found {
 			continue
 		}
-		switch field.Type.Kind() {
-		case reflect.Ptr:
-			if dest.Field(i).IsNil() {
-				dest.Field(i).Set(reflect.New(field.Type.Elem()))
-			}
-			if err := unmarshal(dest.Field(i).Elem(), reflect.ValueOf(value)); err != nil {
-				return fmt.Errorf("unmarshal field %s fail: %s", field.Name, err.Error())
-			}
-		case reflect.Interface:
-			if err := unmarshalInterface(dest.Field(i), reflect.ValueOf(value)); err != nil {
-				return fmt.Errorf("unmarshal field %s fail: %s", field.Name, err.Error())
-			}
-		default:
-			if err := unmarshal(dest.Field(i), reflect.ValueOf(value)); err != nil {
-				return fmt.Errorf("unmarshal field %s fail: %s", field.Name, err.Error())
-			}
+		fieldDecoder := decoder.at(field.Name)
+		if isSecret(field.Tag) {
+			err = fieldDecoder.unmarshalSecret(dest.Field(i), reflect.ValueOf(value), field.Tag)
+		} else if err = fieldDecoder.unmarshal(dest.Field(i), reflect.ValueOf(value), field.Tag); err != nil && isSensitive(field.Tag) {
+			err = redactError(dest.Field(i), err)
+		}
+		if err != nil {
+			return pathError(field.Name, err)
+		}
+		if origin.Kind != "" {
+			fieldDecoder.recordOrigin(origin)
 		}
 	}
 	return nil
 }
 
-func unmarshalPtr(dest, src reflect.Value) error {
-	// always non-nil
-	// if dest.IsNil() {
-	// 	dest.Set(reflect.New(dest.Type().Elem()))
-	// }
-	return unmarshal(reflect.Indirect(dest), src)
+func (decoder *Decoder) unmarshalPtr(dest, src reflect.Value, tag reflect.StructTag) error {
+	// nil for elements of maps and slices
+	if dest.IsNil() {
+		dest.Set(reflect.New(dest.Type().Elem()))
+	}
+	return decoder.unmarshal(reflect.Indirect(dest), src, tag)
 }
 
 func unmarshalText(dest encoding.TextUnmarshaler, src reflect.Value) error {
@@ -341,40 +500,6 @@ func unmarshalText(dest encoding.TextUnmarshaler, src reflect.Value) error {
 	return badtype("string/[]byte", src)
 }
 
-func unmarshalTime(dest, src reflect.Value) error {
-	if src.Kind() != reflect.String {
-		return badtype("string", src)
-	}
-	text := src.String()
-	for _, layout := range timeLayouts {
-		if len(layout) == len(text) {
-			if timeValue, err := time.Parse(layout, text); err == nil {
-				dest.Set(reflect.ValueOf(timeValue))
-				return nil
-			}
-		}
-	}
-	return fmt.Errorf("unknown time layout: %s", text)
-}
-
-func unmarshalDuration(dest, src reflect.Value) error {
-	if src.Kind() != reflect.String {
-		return badtype("string", src)
-	}
-	text := src.String()
-	if text == "genesis" {
-		dest.SetInt(math.MinInt64)
-		return nil
-	} else if text == "doomsday" {
-		dest.SetInt(math.MaxInt64)
-		return nil
-	} else if durationValue, err := time.ParseDuration(text); err == nil {
-		dest.Set(reflect.ValueOf(durationValue))
-		return nil
-	}
-	return fmt.Errorf("invalid duration: %s", text)
-}
-
 func parseIntText(text string) (int64, error) {
 	if text == "0" {
 		return 0, nil
@@ -397,10 +522,12 @@ func parseUintText(text string) (uint64, error) {
 	return strconv.ParseUint(text, 10, 64)
 }
 
-func copySlice(dest, src reflect.Value) error {
-	for i, len := 0, dest.Len(); i < len; i++ {
-		if err := unmarshal(dest.Index(i), src.Index(i)); err != nil {
-			return fmt.Errorf("copy index [%d] error: %s", i, err.Error())
+// copySlice unmarshals the source elements to the destination elements,
+// the offset is the index of the destination in the whole slice for origins.
+func (decoder *Decoder) copySlice(dest, src reflect.Value, offset int, tag reflect.StructTag) error {
+	for i, len := 0, src.Len(); i < len; i++ {
+		if err := decoder.at(indexSegment(offset+i)).unmarshal(dest.Index(i), src.Index(i), tag); err != nil {
+			return pathError(indexSegment(i), err)
 		}
 	}
 	return nil
@@ -426,7 +553,105 @@ func indirect(value reflect.Value) reflect.Value {
 	return indirect(reflect.Indirect(value))
 }
 
+// toStringMap converts maps with keys of strings or stringifiable values,
+// and structs of exported fields to map[string]interface{}.
+func toStringMap(src reflect.Value) (map[string]interface{}, error) {
+	if data, ok := src.Interface().(map[string]interface{}); ok {
+		return data, nil
+	}
+	switch src.Kind() {
+	case reflect.Map:
+		data := make(map[string]interface{}, src.Len())
+		for _, srcKey := range src.MapKeys() {
+			key, err := stringifyKey(srcKey)
+			if err != nil {
+				return nil, err
+			}
+			data[key] = src.MapIndex(srcKey).Interface()
+		}
+		return data, nil
+	case reflect.Struct:
+		data := make(map[string]interface{})
+		structFields(src, data)
+		return data, nil
+	}
+	return nil, badtype("map/struct", src)
+}
+
+// structFields collects exported fields of a struct value, fields of anonymous
+// structs are promoted unless shadowed.
+func structFields(src reflect.Value, data map[string]interface{}) {
+	typ := src.Type()
+	var embedded []reflect.Value
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			if value := reflect.Indirect(src.Field(i)); value.IsValid() {
+				embedded = append(embedded, value)
+			}
+		} else if field.PkgPath == "" {
+			data[field.Name] = src.Field(i).Interface()
+		}
+	}
+	for _, value := range embedded {
+		promoted := make(map[string]interface{})
+		structFields(value, promoted)
+		for key, fieldValue := range promoted {
+			if _, found := data[key]; !found {
+				data[key] = fieldValue
+			}
+		}
+	}
+}
+
+func stringifyKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.Interface {
+		key = key.Elem()
+	}
+	if !key.IsValid() {
+		return "", fmt.Errorf("unsupported map key: %s", badtype("string", key))
+	} else if textMarshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := textMarshaler.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(key.Interface()), nil
+	}
+	return "", fmt.Errorf("unsupported map key: %s", badtype("string", key))
+}
+
+func isNil(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+func isSupportedKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uintptr, reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
 func badtype(expected string, value reflect.Value) error {
+	if !value.IsValid() {
+		return fmt.Errorf("expect %s but found nil", expected)
+	}
 	return fmt.Errorf("expect %s but found %q(%s)",
 		expected, value.Type().Name(), value.Kind())
 }
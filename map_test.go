@@ -340,7 +340,7 @@ func TestUnmarshalMap(t *testing.T) {
 	}
 	// badtype
 	a = nil
-	if err := Unmarshal(&a, ""); err == nil {
+	if err := Unmarshal(&a, 1); err == nil {
 		t.Error("unexpected unmarshal success:", a)
 		return
 	}
@@ -361,7 +361,7 @@ func TestUnmarshalMap(t *testing.T) {
 func TestUnmarshalSlice(t *testing.T) {
 	// badtype
 	var a []int
-	if err := Unmarshal(&a, ""); err == nil {
+	if err := Unmarshal(&a, 1); err == nil {
 		t.Error("unexpected unmarshal success:", a)
 		return
 	}
@@ -455,6 +455,104 @@ func TestUnmarshalStruct(t *testing.T) {
 	}
 }
 
+func TestUnmarshalStructSourceMaps(t *testing.T) {
+	type Inner struct {
+		Count int
+	}
+	type TestStruct struct {
+		Name  string
+		Inner Inner
+		Ports map[int]string
+	}
+	// yaml.v2 style map
+	src := map[interface{}]interface{}{
+		"Name": "a",
+		"Inner": map[interface{}]interface{}{
+			"Count": 1,
+		},
+		"Ports": map[interface{}]interface{}{
+			80: "http",
+		},
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Name != "a" || a.Inner.Count != 1 || a.Ports[80] != "http" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// typed map
+	var b Inner
+	if err := Unmarshal(&b, map[string]int{"Count": 2}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b.Count != 2 {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+	if err := Unmarshal(&b, map[string]string{"Count": "3"}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b.Count != 3 {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+	// stringified key
+	if err := Unmarshal(&b, map[int]int{1: 2}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	// invalid key
+	if err := Unmarshal(&b, map[interface{}]interface{}{[2]int{}: 1}); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+	if err := Unmarshal(&b, map[interface{}]interface{}{nil: 1}); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+}
+
+func TestUnmarshalStructSourceStruct(t *testing.T) {
+	type Source struct {
+		S1
+		Text    string
+		Extra   int
+		Nested  foo
+		private int
+	}
+	type Dest struct {
+		Duration time.Duration
+		Text     string
+		Nested   struct {
+			Text string
+		}
+	}
+	src := Source{
+		S1:     S1{Duration: time.Minute},
+		Text:   "a",
+		Extra:  1,
+		Nested: foo{Text: "b"},
+	}
+	var a Dest
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Duration != time.Minute || a.Text != "a" || a.Nested.Text != "b" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	var b map[string]interface{}
+	if err := Unmarshal(&b, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if b["Text"] != "a" || b["Duration"] != time.Minute {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+}
+
 type UT struct {
 	text string
 }
@@ -538,6 +636,70 @@ func TestUnmarshalDuration(t *testing.T) {
 	}
 }
 
+func TestUnmarshalNil(t *testing.T) {
+	type TestStruct struct {
+		Int       int
+		String    string
+		Ptr       *S1
+		Map       map[string]int
+		Slice     []int
+		Interface stringer
+		Any       interface{}
+		Time      time.Time
+		Duration  time.Duration
+		Struct    S1
+	}
+	a := TestStruct{
+		Int:       1,
+		String:    "a",
+		Ptr:       &S1{},
+		Map:       map[string]int{"a": 1},
+		Slice:     []int{1},
+		Interface: foo{},
+		Any:       1,
+		Time:      time.Now(),
+		Duration:  time.Second,
+		Struct:    S1{Duration: time.Second},
+	}
+	src := map[string]interface{}{
+		"Int":       nil,
+		"String":    nil,
+		"Ptr":       nil,
+		"Map":       nil,
+		"Slice":     nil,
+		"Interface": nil,
+		"Any":       nil,
+		"Time":      nil,
+		"Duration":  (*string)(nil),
+		"Struct":    nil,
+	}
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Int != 0 || a.String != "" || a.Ptr != nil || a.Map != nil || a.Slice != nil ||
+		a.Interface != nil || a.Any != nil || !a.Time.IsZero() || a.Duration != 0 || a.Struct.Duration != 0 {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// elements
+	var b []*S1
+	if err := Unmarshal(&b, []interface{}{nil, map[string]interface{}{}}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(b) != 2 || b[0] != nil || b[1] == nil {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+	var c map[string]int
+	if err := Unmarshal(&c, map[string]interface{}{"a": nil}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if v, found := c["a"]; !found || v != 0 {
+		t.Error("unexpected unmarshal result:", c)
+		return
+	}
+}
+
 func TestParseInt(t *testing.T) {
 	if v, err := parseIntText("0"); err != nil {
 		t.Error("parseIntText fail:", err.Error())
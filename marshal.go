@@ -0,0 +1,174 @@
+package map2struct
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Marshal marshal a value to map[string]interface{}, []interface{} and scalar values,
+// which can be unmarshaled back by Unmarshal.
+func Marshal(src interface{}) (interface{}, error) {
+	return defaultDecoder.Marshal(src)
+}
+
+// Marshal marshal a value with the options of the decoder.
+func (decoder *Decoder) Marshal(src interface{}) (interface{}, error) {
+	return decoder.marshal(reflect.ValueOf(src), "")
+}
+
+func (decoder *Decoder) marshal(src reflect.Value, tag reflect.StructTag) (interface{}, error) {
+	if !src.IsValid() {
+		return nil, nil
+	}
+	switch src.Type() {
+	case timeType:
+		return decoder.marshalTime(src.Interface().(time.Time), tag), nil
+	case durationType:
+		return src.Interface().(time.Duration).String(), nil
+	}
+	if enum := enums[src.Type()]; enum != nil {
+		if name, found := enum.Name(src.Interface()); found {
+			return name, nil
+		}
+	}
+	if text, ok, err := marshalText(src); ok {
+		return text, err
+	}
+	switch src.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return src.Interface(), nil
+	case reflect.Ptr:
+		if src.IsNil() {
+			return nil, nil
+		}
+		return decoder.marshal(src.Elem(), tag)
+	case reflect.Interface:
+		return decoder.marshal(src.Elem(), tag)
+	case reflect.Array, reflect.Slice:
+		return decoder.marshalSlice(src, tag)
+	case reflect.Map:
+		return decoder.marshalMap(src, tag)
+	case reflect.Struct:
+		return decoder.marshalStruct(src)
+	}
+	return nil, fmt.Errorf("unsupported kind: %s", src.Kind())
+}
+
+func (decoder *Decoder) marshalTime(timeValue time.Time, tag reflect.StructTag) interface{} {
+	switch layout := tag.Get("layout"); layout {
+	case "":
+		return timeValue.Format(time.RFC3339Nano)
+	case "unix":
+		return timeValue.Unix()
+	case "unixmilli":
+		return timeValue.UnixNano() / int64(time.Millisecond)
+	case "unixmicro":
+		return timeValue.UnixNano() / int64(time.Microsecond)
+	case "unixnano":
+		return timeValue.UnixNano()
+	default:
+		return timeValue.Format(layout)
+	}
+}
+
+func (decoder *Decoder) marshalSlice(src reflect.Value, tag reflect.StructTag) (interface{}, error) {
+	if src.Kind() == reflect.Slice && src.IsNil() {
+		return nil, nil
+	} else if src.Type().Elem().Kind() == reflect.Uint8 {
+		// Bytes of arrays requires addressable arrays
+		bytes := make([]byte, src.Len())
+		reflect.Copy(reflect.ValueOf(bytes), src)
+		return bytes, nil
+	}
+	result := make([]interface{}, src.Len())
+	for i := range result {
+		value, err := decoder.marshal(src.Index(i), tag)
+		if err != nil {
+			return nil, fmt.Errorf("marshal index [%d] error: %s", i, err.Error())
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func (decoder *Decoder) marshalMap(src reflect.Value, tag reflect.StructTag) (interface{}, error) {
+	if src.IsNil() {
+		return nil, nil
+	}
+	result := make(map[string]interface{}, src.Len())
+	for _, srcKey := range src.MapKeys() {
+		key, err := decoder.marshal(srcKey, tag)
+		if err != nil {
+			return nil, fmt.Errorf("marshal map index [%v] key error: %s",
+				srcKey.Interface(), err.Error())
+		}
+		value, err := decoder.marshal(src.MapIndex(srcKey), tag)
+		if err != nil {
+			return nil, fmt.Errorf("marshal map index [%v] value error: %s",
+				srcKey.Interface(), err.Error())
+		}
+		result[fmt.Sprint(key)] = value
+	}
+	return result, nil
+}
+
+func (decoder *Decoder) marshalStruct(src reflect.Value) (interface{}, error) {
+	result := make(map[string]interface{})
+	typ := src.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && (field.PkgPath == "" || field.Type.Kind() == reflect.Struct) &&
+			reflect.Indirect(src.Field(i)).Kind() == reflect.Struct {
+			// exported fields of unexported anonymous structs are promoted like unmarshalStruct
+			embedded, err := decoder.marshalStruct(reflect.Indirect(src.Field(i)))
+			if err != nil {
+				return nil, fmt.Errorf("marshal anonymous field %q fail: type=%q, error=%q",
+					field.Name, typ, err.Error())
+			}
+			for key, value := range embedded.(map[string]interface{}) {
+				if _, found := result[key]; !found {
+					result[key] = value
+				}
+			}
+			continue
+		} else if field.PkgPath != "" {
+			continue
+		}
+		var value interface{}
+		var err error
+		if isSensitive(field.Tag) {
+			value, err = decoder.marshalSensitive(src.Field(i), field.Tag)
+		} else {
+			value, err = decoder.marshal(src.Field(i), field.Tag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("marshal field %s fail: %s", field.Name, err.Error())
+		}
+		result[field.Name] = value
+	}
+	return result, nil
+}
+
+// marshalText marshals values implementing encoding.TextMarshaler, and values
+// of types with converter implementing fmt.Stringer.
+func marshalText(src reflect.Value) (string, bool, error) {
+	values := []reflect.Value{src}
+	if src.CanAddr() {
+		values = append(values, src.Addr())
+	}
+	for _, value := range values {
+		if !value.CanInterface() {
+			continue
+		} else if textMarshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+			text, err := textMarshaler.MarshalText()
+			return string(text), true, err
+		} else if stringer, ok := value.Interface().(fmt.Stringer); ok && converters[src.Type()] != nil {
+			return stringer.String(), true, nil
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,105 @@
+package map2struct
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	levelType := reflect.TypeOf(levelDebug)
+	defer delete(enums, levelType)
+	RegisterEnum(levelType, map[string]interface{}{
+		"debug": levelDebug,
+		"info":  levelInfo,
+	})
+	now := time.Now()
+	type TestStruct struct {
+		S1
+		Level    level
+		Levels   []level
+		Time     time.Time
+		Unix     time.Time `layout:"unix"`
+		URL      *url.URL
+		Nil      *S1
+		Map      map[int]string
+		Set      map[string]bool
+		Bytes    []byte
+		private  int
+		Stringer stringer
+	}
+	src := TestStruct{
+		S1:       S1{Duration: time.Second},
+		Level:    levelInfo,
+		Levels:   []level{levelDebug, level(5)},
+		Time:     now,
+		Unix:     now,
+		URL:      &url.URL{Scheme: "http", Host: "example.com"},
+		Map:      map[int]string{1: "a"},
+		Set:      map[string]bool{"b": true},
+		Bytes:    []byte("bytes"),
+		Stringer: foo{Text: "hello"},
+	}
+	result, err := Marshal(src)
+	if err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"Duration": "1s",
+		"Level":    "info",
+		"Levels":   []interface{}{"debug", level(5)},
+		"Time":     now.Format(time.RFC3339Nano),
+		"Unix":     now.Unix(),
+		"URL":      "http://example.com",
+		"Nil":      nil,
+		"Map":      map[string]interface{}{"1": "a"},
+		"Set":      map[string]interface{}{"b": true},
+		"Bytes":    []byte("bytes"),
+		"Stringer": map[string]interface{}{"Text": "hello"},
+	}
+	if !reflect.DeepEqual(result, expect) {
+		t.Errorf("unexpected marshal result: expect=%v, actual=%v", expect, result)
+		return
+	}
+	// round trip
+	delete(result.(map[string]interface{}), "Stringer")
+	var dest TestStruct
+	if err := Unmarshal(&dest, result); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if dest.Duration != time.Second || dest.Level != levelInfo || !dest.Time.Equal(now) || dest.URL.Host != "example.com" {
+		t.Error("unexpected unmarshal result:", dest)
+		return
+	}
+	// byte arrays are not addressable
+	if result, err := Marshal(struct{ A [4]byte }{[4]byte{1}}); err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	} else if expect := map[string]interface{}{"A": []byte{1, 0, 0, 0}}; !reflect.DeepEqual(result, expect) {
+		t.Error("unexpected marshal result:", result)
+		return
+	}
+	// fields of unexported anonymous structs are promoted
+	type inner struct{ A int }
+	type outer struct {
+		inner
+		B int
+	}
+	if result, err := Marshal(outer{inner{1}, 2}); err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	} else if expect := map[string]interface{}{"A": 1, "B": 2}; !reflect.DeepEqual(result, expect) {
+		t.Error("unexpected marshal result:", result)
+		return
+	} else if dest := (outer{}); Unmarshal(&dest, result) != nil || dest != (outer{inner{1}, 2}) {
+		t.Errorf("unexpected unmarshal result: %+v", dest)
+		return
+	}
+	// unsupported kind
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Error("unexpected marshal success")
+		return
+	}
+}
@@ -0,0 +1,165 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeStrategy defines how source values are merged into existing slices and maps.
+// A field can override the strategy of the decoder with the `merge` tag, like `merge:"append"`.
+type MergeStrategy string
+
+const (
+	// MergeReplace replaces the existing slice or map with a new one.
+	MergeReplace MergeStrategy = "replace"
+	// MergeAppend appends the source elements to the existing slice.
+	MergeAppend MergeStrategy = "append"
+	// MergeTruncate unmarshals the source elements onto the existing elements
+	// of the slice, and truncates the slice to the source length.
+	MergeTruncate MergeStrategy = "truncate"
+	// MergeMerge merges the source into the existing map, values of existing keys
+	// are unmarshaled onto. For slices, the source elements are unmarshaled onto
+	// the existing elements, and the trailing existing elements are kept.
+	MergeMerge MergeStrategy = "merge"
+
+	mergeKeyPrefix = "key:"
+)
+
+// MergeByKey returns the strategy merging slices of structs by the key field.
+// Source elements are unmarshaled onto the existing element with the same key,
+// or appended if no element has the key. Existing elements absent in the source are kept.
+func MergeByKey(field string) MergeStrategy {
+	return MergeStrategy(mergeKeyPrefix + field)
+}
+
+func (strategy MergeStrategy) key() string {
+	if strings.HasPrefix(string(strategy), mergeKeyPrefix) {
+		return string(strategy[len(mergeKeyPrefix):])
+	}
+	return ""
+}
+
+// mergeStrategy returns the strategy of the `merge` tag for the slice or map type.
+// Slice strategies on maps of slices, like `merge:"append"` of map[string][]string,
+// apply to the nested slices, and the maps use the default strategy.
+func mergeStrategy(tag reflect.StructTag, strategy MergeStrategy, typ reflect.Type) MergeStrategy {
+	merge := MergeStrategy(tag.Get("merge"))
+	if merge == "" {
+		return strategy
+	} else if typ != nil && typ.Kind() == reflect.Map && (merge == MergeAppend || merge == MergeTruncate || merge.key() != "") {
+		elemType := typ.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() == reflect.Slice {
+			return strategy
+		}
+	}
+	return merge
+}
+
+// strategy returns the merge strategy of the field, tags are ignored in patching.
+func (decoder *Decoder) strategy(tag reflect.StructTag, strategy MergeStrategy, typ reflect.Type) MergeStrategy {
+	if decoder.patching {
+		return strategy
+	}
+	return mergeStrategy(tag, strategy, typ)
+}
+
+// prepareSlice prepares the destination slice for the source length according
+// to the merge strategy, and returns the slice where the source is copied to.
+func (decoder *Decoder) prepareSlice(dest reflect.Value, length int, strategy MergeStrategy) (reflect.Value, error) {
+	var result reflect.Value
+	var offset int
+	switch strategy {
+	case MergeReplace:
+		result = reflect.MakeSlice(dest.Type(), length, length)
+	case MergeAppend:
+		offset = dest.Len()
+		result = reflect.MakeSlice(dest.Type(), offset+length, offset+length)
+		reflect.Copy(result, dest)
+	case MergeTruncate, "":
+		result = reflect.MakeSlice(dest.Type(), length, length)
+		reflect.Copy(result, dest)
+	case MergeMerge:
+		if dest.Len() >= length {
+			return dest, nil
+		}
+		result = reflect.MakeSlice(dest.Type(), length, length)
+		reflect.Copy(result, dest)
+	default:
+		return reflect.Value{}, fmt.Errorf("unknown slice merge strategy: %q", strategy)
+	}
+	dest.Set(result)
+	return dest.Slice(offset, offset+length), nil
+}
+
+// prepareMap prepares the destination map according to the merge strategy.
+func (decoder *Decoder) prepareMap(dest reflect.Value, strategy MergeStrategy) error {
+	switch strategy {
+	case MergeReplace:
+		dest.Set(reflect.MakeMap(dest.Type()))
+	case MergeMerge, "":
+		if dest.IsNil() {
+			dest.Set(reflect.MakeMap(dest.Type()))
+		}
+	default:
+		return fmt.Errorf("unknown map merge strategy: %q", strategy)
+	}
+	return nil
+}
+
+// mergeSliceByKey merges the source elements into the destination slice by the key field.
+func (decoder *Decoder) mergeSliceByKey(dest, src reflect.Value, key string, tag reflect.StructTag) error {
+	elemType := dest.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	keyField, found := structType.FieldByName(key)
+	if structType.Kind() != reflect.Struct || !found {
+		return fmt.Errorf("merge key %q is not a field of %s", key, elemType)
+	}
+	result := reflect.MakeSlice(dest.Type(), dest.Len(), dest.Len()+src.Len())
+	reflect.Copy(result, dest)
+	for i := 0; i < src.Len(); i++ {
+		item := src.Index(i)
+		for item.Kind() == reflect.Interface || item.Kind() == reflect.Ptr {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Map && item.Kind() != reflect.Struct {
+			if !item.IsValid() {
+				return pathError(indexSegment(i), fmt.Errorf("expect map or struct but found nil"))
+			}
+			return pathError(indexSegment(i), fmt.Errorf("expect map or struct but found %s", item.Type()))
+		}
+		// items are normalized like yaml.v2 maps of interface{} keys and structs
+		data, err := toStringMap(item)
+		if err != nil {
+			return pathError(indexSegment(i), err)
+		}
+		keyValue := reflect.New(keyField.Type).Elem()
+		if srcKey, found := data[key]; !found {
+			return pathError(indexSegment(i), fmt.Errorf("missing merge key %q", key))
+		} else if err := decoder.unmarshal(keyValue, reflect.ValueOf(srcKey), keyField.Tag); err != nil {
+			return pathError(indexSegment(i), pathError(key, err))
+		}
+		index := -1
+		for j := 0; j < result.Len() && index < 0; j++ {
+			if elem := reflect.Indirect(result.Index(j)); elem.IsValid() &&
+				reflect.DeepEqual(elem.FieldByIndex(keyField.Index).Interface(), keyValue.Interface()) {
+				index = j
+			}
+		}
+		if index < 0 {
+			result = reflect.Append(result, reflect.Zero(elemType))
+			index = result.Len() - 1
+		}
+		if err := decoder.at(indexSegment(index)).unmarshal(result.Index(index), item, tag); err != nil {
+			return pathError(indexSegment(i), err)
+		}
+	}
+	dest.Set(result)
+	return nil
+}
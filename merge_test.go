@@ -0,0 +1,207 @@
+package map2struct
+
+import (
+	"testing"
+)
+
+type mergeItem struct {
+	ID    int
+	Name  string
+	Count int
+}
+
+func TestMergeSlice(t *testing.T) {
+	type TestStruct struct {
+		Truncate []int
+		Replace  []mergeItem `merge:"replace"`
+		Append   []int       `merge:"append"`
+		Merge    []int       `merge:"merge"`
+		Reuse    []mergeItem
+	}
+	a := TestStruct{
+		Truncate: []int{1, 2, 3},
+		Replace:  []mergeItem{{ID: 1, Name: "a"}},
+		Append:   []int{1, 2},
+		Merge:    []int{1, 2, 3},
+		Reuse:    []mergeItem{{ID: 1, Name: "a"}, {ID: 2}},
+	}
+	truncate := a.Truncate
+	src := map[string]interface{}{
+		"Truncate": []int{4},
+		"Replace":  []interface{}{map[string]interface{}{"Count": 1}},
+		"Append":   []int{3},
+		"Merge":    []int{4},
+		"Reuse":    []interface{}{map[string]interface{}{"Count": 1}},
+	}
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if len(a.Truncate) != 1 || a.Truncate[0] != 4 || truncate[0] != 1 {
+		t.Error("unexpected truncate result:", a.Truncate, truncate)
+		return
+	}
+	if len(a.Replace) != 1 || a.Replace[0] != (mergeItem{Count: 1}) {
+		t.Error("unexpected replace result:", a.Replace)
+		return
+	}
+	if len(a.Append) != 3 || a.Append[2] != 3 {
+		t.Error("unexpected append result:", a.Append)
+		return
+	}
+	if len(a.Merge) != 3 || a.Merge[0] != 4 || a.Merge[2] != 3 {
+		t.Error("unexpected merge result:", a.Merge)
+		return
+	}
+	if len(a.Reuse) != 1 || a.Reuse[0] != (mergeItem{ID: 1, Name: "a", Count: 1}) {
+		t.Error("unexpected reuse result:", a.Reuse)
+		return
+	}
+	// unknown strategy
+	type BadStruct struct {
+		Slice []int `merge:"unknown"`
+	}
+	var b BadStruct
+	if err := Unmarshal(&b, map[string]interface{}{"Slice": []int{1}}); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+}
+
+func TestMergeSliceByKey(t *testing.T) {
+	type TestStruct struct {
+		Items    []mergeItem  `merge:"key:ID"`
+		Pointers []*mergeItem `merge:"key:Name"`
+	}
+	a := TestStruct{
+		Items:    []mergeItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}},
+		Pointers: []*mergeItem{{Name: "a"}, nil},
+	}
+	src := map[string]interface{}{
+		"Items": []interface{}{
+			map[string]interface{}{"ID": "2", "Count": 5},
+			map[string]interface{}{"ID": 3, "Name": "c"},
+		},
+		"Pointers": []interface{}{
+			map[string]interface{}{"Name": "a", "Count": 1},
+		},
+	}
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := []mergeItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Count: 5}, {ID: 3, Name: "c"}}
+	if len(a.Items) != len(expect) {
+		t.Error("unexpected unmarshal result:", a.Items)
+		return
+	}
+	for i := range expect {
+		if a.Items[i] != expect[i] {
+			t.Error("unexpected unmarshal result:", a.Items)
+			return
+		}
+	}
+	if len(a.Pointers) != 2 || *a.Pointers[0] != (mergeItem{Name: "a", Count: 1}) {
+		t.Error("unexpected unmarshal result:", a.Pointers)
+		return
+	}
+	// yaml.v2 maps and structs
+	if err := Unmarshal(&a, map[string]interface{}{
+		"Items": []interface{}{
+			map[interface{}]interface{}{"ID": 1, "Count": 7},
+			mergeItem{ID: 4, Name: "d"},
+		},
+	}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(a.Items) != 4 || a.Items[0] != (mergeItem{ID: 1, Name: "a", Count: 7}) || a.Items[3] != (mergeItem{ID: 4, Name: "d"}) {
+		t.Error("unexpected unmarshal result:", a.Items)
+		return
+	}
+	// missing key
+	if err := Unmarshal(&a, map[string]interface{}{
+		"Items": []interface{}{map[string]interface{}{"Name": "x"}},
+	}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// not map
+	if err := Unmarshal(&a, map[string]interface{}{
+		"Items": []interface{}{1},
+	}); err == nil || err.Error() != "Items[0]: expect map or struct but found int" {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// unknown key field
+	decoder := NewDecoder()
+	decoder.SliceMerge = MergeByKey("Unknown")
+	var b []mergeItem
+	if err := decoder.Unmarshal(&b, []interface{}{map[string]interface{}{"ID": 1}}); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+}
+
+func TestMergeMap(t *testing.T) {
+	type TestStruct struct {
+		Merge   map[string]mergeItem
+		Replace map[string]int  `merge:"replace"`
+		Set     map[string]bool `merge:"replace"`
+		Bad     map[string]int  `merge:"append"`
+	}
+	a := TestStruct{
+		Merge:   map[string]mergeItem{"a": {ID: 1, Name: "a"}, "b": {ID: 2}},
+		Replace: map[string]int{"a": 1},
+		Set:     map[string]bool{"a": true},
+	}
+	src := map[string]interface{}{
+		"Merge": map[string]interface{}{
+			"a": map[string]interface{}{"Count": 3},
+		},
+		"Replace": map[string]interface{}{"b": 2},
+		"Set":     []string{"b"},
+	}
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if len(a.Merge) != 2 || a.Merge["a"] != (mergeItem{ID: 1, Name: "a", Count: 3}) {
+		t.Error("unexpected merge result:", a.Merge)
+		return
+	}
+	if len(a.Replace) != 1 || a.Replace["b"] != 2 {
+		t.Error("unexpected replace result:", a.Replace)
+		return
+	}
+	if len(a.Set) != 1 || !a.Set["b"] {
+		t.Error("unexpected set result:", a.Set)
+		return
+	}
+	if err := Unmarshal(&a, map[string]interface{}{"Bad": map[string]interface{}{}}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// slice strategies of maps apply to the nested slices
+	var c struct {
+		Lists map[string][]string `merge:"append"`
+	}
+	c.Lists = map[string][]string{"a": {"1"}, "b": {"2"}}
+	if err := Unmarshal(&c, map[string]interface{}{"Lists": map[string]interface{}{"a": []string{"3"}}}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(c.Lists) != 2 || len(c.Lists["a"]) != 2 || c.Lists["a"][1] != "3" {
+		t.Error("unexpected unmarshal result:", c.Lists)
+		return
+	}
+	// decoder option
+	decoder := NewDecoder()
+	decoder.MapMerge = MergeReplace
+	b := map[string]int{"a": 1}
+	if err := decoder.Unmarshal(&b, map[string]interface{}{"b": 2}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(b) != 1 || b["b"] != 2 {
+		t.Error("unexpected unmarshal result:", b)
+		return
+	}
+}
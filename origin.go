@@ -0,0 +1,99 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OriginKind is the kind of the origin of a decoded value.
+type OriginKind string
+
+const (
+	// OriginSource is a value of the source.
+	OriginSource OriginKind = "source"
+	// OriginDefault is a value of the `default` tag, used if the source has no value
+	// and the field is zero.
+	OriginDefault OriginKind = "default"
+	// OriginFactory is an instance created or registered in a factory.
+	OriginFactory OriginKind = "factory"
+)
+
+// Origin is where a decoded value came from.
+type Origin struct {
+	Kind OriginKind
+
+	// Name describes the origin, like the text of the default tag (Redacted for
+	// sensitive fields) or the type of the factory instance. Names of source values
+	// are empty, and set by readers of sources like "config.yaml:3:7" or "APP_DB_HOST".
+	Name string
+}
+
+func (origin Origin) String() string {
+	if origin.Name == "" {
+		return string(origin.Kind)
+	}
+	return string(origin.Kind) + " " + origin.Name
+}
+
+// UnmarshalOrigins unmarshals like Unmarshal, and returns the origins of decoded fields
+// by paths like "Servers[0].Port" of errors.
+func UnmarshalOrigins(dest, src interface{}) (map[string]Origin, error) {
+	return defaultDecoder.UnmarshalOrigins(dest, src)
+}
+
+// UnmarshalOrigins unmarshals with the options of the decoder, and returns the origins of decoded fields.
+// The origins are returned with errors, for the fields decoded before the error.
+func (decoder *Decoder) UnmarshalOrigins(dest, src interface{}) (map[string]Origin, error) {
+	recorder := *decoder
+	recorder.origins = make(map[string]Origin)
+	return recorder.origins, recorder.Unmarshal(dest, src)
+}
+
+// at returns a copy of the decoder at the child path, if origins are recorded.
+func (decoder *Decoder) at(segment string) *Decoder {
+	if decoder.origins == nil {
+		return decoder
+	}
+	child := *decoder
+	child.path = joinPath(decoder.path, segment)
+	return &child
+}
+
+// recordOrigin records the origin of the value at the path of the decoder,
+// unless an origin is recorded by inner values, like factory instances.
+func (decoder *Decoder) recordOrigin(origin Origin) {
+	if decoder.origins == nil {
+		return
+	} else if _, found := decoder.origins[decoder.path]; !found {
+		decoder.origins[decoder.path] = origin
+	}
+}
+
+// factoryOrigin returns the origin of a factory instance.
+func factoryOrigin(instance interface{}) Origin {
+	return Origin{Kind: OriginFactory, Name: fmt.Sprintf("%T", instance)}
+}
+
+// defaultOrigin returns the origin of the default tag of the field.
+func defaultOrigin(field reflect.StructField, text string) Origin {
+	if isSensitive(field.Tag) && text != "" {
+		text = Redacted
+	}
+	return Origin{Kind: OriginDefault, Name: text}
+}
+
+// hasDefaults returns whether the struct type has fields of the default tag, including nested structs.
+func hasDefaults(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		} else if _, found := field.Tag.Lookup("default"); found || hasDefaults(field.Type) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package map2struct
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testOriginServer struct {
+	Host string
+	Port int `default:"80"`
+}
+
+type testOriginConfig struct {
+	Name     string
+	Timeout  time.Duration `default:"30s"`
+	Password string        `default:"x" sensitive:""`
+	Server   testOriginServer
+	Servers  []testOriginServer
+	Labels   map[string]testOriginServer
+	Handler  stringer
+}
+
+func TestUnmarshalOrigins(t *testing.T) {
+	defer func() { factories = make(map[string]Factory) }()
+	factory := NewGeneralInterfaceFactory(reflect.TypeOf((*stringer)(nil)).Elem(), "type", nil)
+	factory.RegisterInstance("Instance", &foo{Text: "instance"})
+	RegisterFactory(factory)
+	src := map[string]interface{}{
+		"Name":    "app",
+		"Servers": []interface{}{map[string]interface{}{"Host": "a", "Port": 81}, map[string]interface{}{"Host": "b"}},
+		"Labels":  map[string]interface{}{"x": map[string]interface{}{"Host": "c"}},
+		"Handler": map[string]interface{}{"type": "Instance"},
+	}
+	var config testOriginConfig
+	origins, err := UnmarshalOrigins(&config, src)
+	if err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if config.Timeout != 30*time.Second || config.Password != "x" || config.Server.Port != 80 ||
+		config.Servers[1].Port != 80 || config.Labels["x"].Port != 80 {
+		t.Errorf("unexpected config: %+v", config)
+		return
+	}
+	source := Origin{Kind: OriginSource}
+	expect := map[string]Origin{
+		"Name":            source,
+		"Timeout":         {Kind: OriginDefault, Name: "30s"},
+		"Password":        {Kind: OriginDefault, Name: Redacted},
+		"Server.Port":     {Kind: OriginDefault, Name: "80"},
+		"Servers":         source,
+		"Servers[0].Host": source,
+		"Servers[0].Port": source,
+		"Servers[1].Host": source,
+		"Servers[1].Port": {Kind: OriginDefault, Name: "80"},
+		"Labels":          source,
+		"Labels[x].Host":  source,
+		"Labels[x].Port":  {Kind: OriginDefault, Name: "80"},
+		"Handler":         {Kind: OriginFactory, Name: "*map2struct.foo"},
+	}
+	if !reflect.DeepEqual(origins, expect) {
+		t.Error("unexpected origins:", origins)
+		return
+	}
+	if origin := origins["Handler"].String(); origin != "factory *map2struct.foo" {
+		t.Error("unexpected origin text:", origin)
+		return
+	}
+	// defaults do not override values of earlier sources
+	config.Timeout = time.Second
+	if origins, err := UnmarshalOrigins(&config, map[string]interface{}{}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if config.Timeout != time.Second || len(origins) != 0 {
+		t.Error("unexpected origins:", config.Timeout, origins)
+		return
+	}
+	// without origins
+	var another testOriginConfig
+	if err := Unmarshal(&another, map[string]interface{}{}); err != nil || another.Server.Port != 80 {
+		t.Error("unexpected unmarshal result:", another, err)
+		return
+	}
+}
@@ -0,0 +1,336 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Patch applies the partial map to the destination like JSON Merge Patch (RFC 7386).
+// Absent keys are untouched, null values clear fields and delete map keys, nested
+// structs, maps and interface{} values are merged, and slices, arrays and sets are
+// replaced. The `merge` and `default` tags and the NilMode, SliceMerge and MapMerge options are ignored.
+func Patch(dest interface{}, patch map[string]interface{}) error {
+	return defaultDecoder.Patch(dest, patch)
+}
+
+// Patch applies the partial map with the other options of the decoder.
+func (decoder *Decoder) Patch(dest interface{}, patch map[string]interface{}) error {
+	patcher := *decoder
+	patcher.NilMode = NilZero
+	patcher.SliceMerge = MergeReplace
+	patcher.MapMerge = MergeMerge
+	patcher.patching = true
+	return patcher.Unmarshal(dest, patch)
+}
+
+// MergePatch applies the patch to the target by JSON Merge Patch (RFC 7386), and returns the result.
+// Maps are merged recursively and null values delete keys, the other values replace the target.
+// The target is not modified.
+func MergePatch(target, patch interface{}) interface{} {
+	patchValue := reflect.ValueOf(patch)
+	if patchValue.Kind() != reflect.Map {
+		return patch
+	}
+	patchData, err := toStringMap(patchValue)
+	if err != nil {
+		return patch
+	}
+	var targetData map[string]interface{}
+	if targetValue := reflect.ValueOf(target); targetValue.Kind() == reflect.Map {
+		targetData, _ = toStringMap(targetValue)
+	}
+	result := make(map[string]interface{}, len(targetData)+len(patchData))
+	for key, value := range targetData {
+		result[key] = value
+	}
+	for key, value := range patchData {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = MergePatch(result[key], value)
+		}
+	}
+	return result
+}
+
+// PatchOperation is an operation of JSON Patch (RFC 6902), paths are JSON pointers
+// (RFC 6901) like "/servers/0/port".
+type PatchOperation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" and "test".
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// JSONPatch applies the operations to the document by JSON Patch (RFC 6902), and returns the result.
+// The operations are applied atomically: the document is not modified, and nothing is
+// returned if any operation fails. Numbers of different types are equal in "test" if
+// they have the same value.
+func JSONPatch(doc interface{}, operations []PatchOperation) (interface{}, error) {
+	doc = copyDocument(reflect.ValueOf(doc))
+	for i, operation := range operations {
+		var err error
+		if doc, err = applyOperation(doc, operation); err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %q): %s", i, operation.Op, operation.Path, err.Error())
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, operation PatchOperation) (interface{}, error) {
+	tokens, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch operation.Op {
+	case "add":
+		return addValue(doc, tokens, copyDocument(reflect.ValueOf(operation.Value)))
+	case "remove":
+		doc, _, err = removeValue(doc, tokens)
+		return doc, err
+	case "replace":
+		if doc, _, err = removeValue(doc, tokens); err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, copyDocument(reflect.ValueOf(operation.Value)))
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if operation.Op == "copy" {
+			if value, err = getValue(doc, from); err != nil {
+				return nil, err
+			}
+			value = copyDocument(reflect.ValueOf(value))
+		} else if len(from) < len(tokens) && reflect.DeepEqual(from, tokens[:len(from)]) {
+			return nil, fmt.Errorf("cannot move %q to its child", operation.From)
+		} else if doc, value, err = removeValue(doc, from); err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, value)
+	case "test":
+		value, err := getValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		} else if !documentEqual(value, operation.Value) {
+			return nil, fmt.Errorf("test failed: %v != %v", value, operation.Value)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation: %q", operation.Op)
+}
+
+// parsePointer parses a JSON pointer to reference tokens, "~1" is "/" and "~0" is "~".
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	} else if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q: missing leading \"/\"", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] != '~' {
+				continue
+			} else if j+1 >= len(token) || token[j+1] != '0' && token[j+1] != '1' {
+				return nil, fmt.Errorf("invalid pointer %q: invalid escape", pointer)
+			}
+			j++
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an index of array tokens, "-" is the length if appendable.
+func arrayIndex(token string, length int, appendable bool) (int, error) {
+	if token == "-" && appendable {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || token != strconv.Itoa(index) {
+		return 0, fmt.Errorf("invalid array index: %q", token)
+	} else if index > length || index == length && !appendable {
+		return 0, fmt.Errorf("array index out of range: %d", index)
+	}
+	return index, nil
+}
+
+// getValue returns the value at the tokens.
+func getValue(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, found := container[token]
+			if !found {
+				return nil, fmt.Errorf("key not found: %q", token)
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("not a container at %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// updateParent calls the function with the parent of the last token, and returns the updated document.
+func updateParent(doc interface{}, tokens []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(doc, tokens[0])
+	}
+	child, err := getValue(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = updateParent(child, tokens[1:], update); err != nil {
+		return nil, err
+	}
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		container[tokens[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(tokens[0], len(container), false)
+		container[index] = child
+	}
+	return doc, nil
+}
+
+// addValue adds the value at the tokens, array elements are inserted.
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("not a container at %q", token)
+	})
+}
+
+// removeValue removes the value at the tokens, and returns the removed value.
+func removeValue(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	doc, err := updateParent(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			value, found := container[token]
+			if !found {
+				return nil, fmt.Errorf("key not found: %q", token)
+			}
+			removed = value
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[index]
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("not a container at %q", token)
+	})
+	return doc, removed, err
+}
+
+// copyDocument deep copies maps and slices to map[string]interface{} and []interface{}.
+func copyDocument(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Map:
+		if data, err := toStringMap(value); err == nil {
+			result := make(map[string]interface{}, len(data))
+			for key, item := range data {
+				result[key] = copyDocument(reflect.ValueOf(item))
+			}
+			return result
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() != reflect.Uint8 {
+			result := make([]interface{}, value.Len())
+			for i := range result {
+				result[i] = copyDocument(value.Index(i))
+			}
+			return result
+		}
+	}
+	return value.Interface()
+}
+
+// documentEqual compares values of documents, numbers of different types are compared by values.
+func documentEqual(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isNumber(va) && isNumber(vb) {
+		return numberValue(va) == numberValue(vb)
+	}
+	a, b = copyDocument(va), copyDocument(vb)
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, item := range a {
+			if other, found := b[key]; !found || !documentEqual(item, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !documentEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isNumber(value reflect.Value) bool {
+	return value.Kind() >= reflect.Int && value.Kind() <= reflect.Float64 && value.Kind() != reflect.Uintptr
+}
+
+func numberValue(value reflect.Value) float64 {
+	switch {
+	case value.Kind() >= reflect.Int && value.Kind() <= reflect.Int64:
+		return float64(value.Int())
+	case value.Kind() >= reflect.Uint && value.Kind() <= reflect.Uint64:
+		return float64(value.Uint())
+	}
+	return value.Float()
+}
@@ -0,0 +1,184 @@
+package map2struct
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testPatchServer struct {
+	Host string
+	Port int
+}
+
+type testPatchConfig struct {
+	Name    string
+	Port    *int
+	Server  testPatchServer
+	Servers []testPatchServer `merge:"append"`
+	Tags    map[string]bool
+	Labels  map[string]string `merge:"replace"`
+	Extra   interface{}
+}
+
+func TestPatch(t *testing.T) {
+	port := 80
+	config := testPatchConfig{
+		Name:    "app",
+		Port:    &port,
+		Server:  testPatchServer{Host: "a", Port: 80},
+		Servers: []testPatchServer{{"a", 80}, {"b", 81}},
+		Tags:    map[string]bool{"x": true},
+		Labels:  map[string]string{"a": "1", "b": "2"},
+		Extra:   map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2, "d": 3}},
+	}
+	patch := map[string]interface{}{
+		"Port":    nil,
+		"Server":  map[string]interface{}{"Port": 8080},
+		"Servers": []interface{}{map[string]interface{}{"Host": "c"}},
+		"Tags":    []interface{}{"y"},
+		"Labels":  map[string]interface{}{"a": nil, "c": "3"},
+		"Extra":   map[string]interface{}{"a": nil, "b": map[string]interface{}{"c": 4, "e": map[string]interface{}{"f": nil}}},
+	}
+	if err := Patch(&config, patch); err != nil {
+		t.Error("patch fail:", err.Error())
+		return
+	}
+	expect := testPatchConfig{
+		Name:    "app",
+		Server:  testPatchServer{Host: "a", Port: 8080},
+		Servers: []testPatchServer{{Host: "c"}},
+		Tags:    map[string]bool{"y": true},
+		Labels:  map[string]string{"b": "2", "c": "3"},
+		Extra:   map[string]interface{}{"b": map[string]interface{}{"c": 4, "d": 3, "e": map[string]interface{}{}}},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("unexpected config: %+v", config)
+		return
+	}
+	if err := Patch(&config, map[string]interface{}{"Server": nil, "Extra": nil}); err != nil {
+		t.Error("patch fail:", err.Error())
+		return
+	} else if config.Server != (testPatchServer{}) || config.Extra != nil {
+		t.Errorf("unexpected config: %+v", config)
+		return
+	}
+	// options of the decoder are kept out of patches
+	if err := Unmarshal(&config, map[string]interface{}{"Servers": []interface{}{map[string]interface{}{"Host": "d"}}}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if len(config.Servers) != 2 {
+		t.Error("unexpected servers:", config.Servers)
+		return
+	}
+	// defaults are not used for absent keys
+	type Def struct {
+		Port   int `default:"80"`
+		Name   string
+		Server testOriginServer
+	}
+	def := Def{Port: 0, Name: "a"}
+	if err := Patch(&def, map[string]interface{}{"Name": "b"}); err != nil {
+		t.Error("patch fail:", err.Error())
+		return
+	} else if def != (Def{Name: "b"}) {
+		t.Errorf("unexpected patch result: %+v", def)
+		return
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples of RFC 7386
+	cases := []struct {
+		target, patch, expect string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		var target, patch, expect interface{}
+		json.Unmarshal([]byte(c.target), &target)
+		json.Unmarshal([]byte(c.patch), &patch)
+		json.Unmarshal([]byte(c.expect), &expect)
+		if result := MergePatch(target, patch); !reflect.DeepEqual(result, expect) {
+			t.Errorf("unexpected merge patch result of %s and %s: %v", c.target, c.patch, result)
+			return
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// examples of RFC 6902
+	cases := []struct {
+		doc, patch, expect string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{`{"foo":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, c := range cases {
+		var doc, expect interface{}
+		var operations []PatchOperation
+		json.Unmarshal([]byte(c.doc), &doc)
+		json.Unmarshal([]byte(c.patch), &operations)
+		json.Unmarshal([]byte(c.expect), &expect)
+		if result, err := JSONPatch(doc, operations); err != nil {
+			t.Errorf("patch %s fail: %s", c.patch, err.Error())
+			return
+		} else if !reflect.DeepEqual(result, expect) {
+			t.Errorf("unexpected patch result of %s: %v", c.patch, result)
+			return
+		}
+	}
+	failures := map[string]string{
+		`[{"op":"add","path":"/baz/bat","value":"qux"}]`:                            `key not found: "baz"`,
+		`[{"op":"test","path":"/foo","value":"x"}]`:                                 "test failed",
+		`[{"op":"add","path":"/list/3","value":1}]`:                                 "array index out of range: 3",
+		`[{"op":"remove","path":"/list/01"}]`:                                       `invalid array index: "01"`,
+		`[{"op":"move","from":"/obj","path":"/obj/a"}]`:                             "cannot move",
+		`[{"op":"invalid","path":"/foo"}]`:                                          "unknown operation",
+		`[{"op":"remove","path":"foo"}]`:                                            "invalid pointer",
+		`[{"op":"remove","path":"/a~2"}]`:                                           "invalid escape",
+		`[{"op":"remove","path":"/foo"},{"op":"test","path":"/foo","value":"bar"}]`: `patch operation 1`,
+	}
+	doc := map[string]interface{}{"foo": "bar", "list": []interface{}{1, 2}, "obj": map[string]interface{}{}}
+	for patch, message := range failures {
+		var operations []PatchOperation
+		json.Unmarshal([]byte(patch), &operations)
+		if _, err := JSONPatch(doc, operations); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("unexpected patch result of %s: %v", patch, err)
+			return
+		}
+	}
+	if len(doc) != 3 || doc["foo"] != "bar" {
+		t.Error("unexpected modified document:", doc)
+		return
+	}
+}
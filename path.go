@@ -0,0 +1,178 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// UnmarshalPath unmarshals the sub-tree of the source at the path to the destination.
+// The path is a list of keys separated by dots like "services.billing.retry",
+// slice indexes are written as "servers[0]" or "servers.0", and dots, brackets
+// and backslashes in keys are escaped by backslash like "hosts.example\.com".
+func UnmarshalPath(dest, src interface{}, path string) error {
+	return defaultDecoder.UnmarshalPath(dest, src, path)
+}
+
+// UnmarshalPath unmarshals the sub-tree of the source at the path with the options of the decoder.
+// Paths of errors are reported from the root of the source.
+func (decoder *Decoder) UnmarshalPath(dest, src interface{}, path string) error {
+	if value := reflect.ValueOf(dest); value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("invalid destination: expect non-nil pointer but found %T", dest)
+	}
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	root := reflect.ValueOf(src)
+	value, prefix, err := lookupPath(root, segments)
+	if err != nil {
+		return err
+	}
+	if err := decoder.withSource(root).unmarshal(rvalue(dest), value, ""); err != nil {
+		return pathError(prefix, err)
+	}
+	return nil
+}
+
+// pathSegment is a key or an index of a path.
+type pathSegment struct {
+	key   string
+	index int
+}
+
+func (segment pathSegment) isIndex() bool {
+	return segment.index >= 0
+}
+
+// parsePath parses a path expression to segments.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	var key []rune
+	// pending is true if a key is being read, it allows empty keys like "a..b" to be reported
+	pending := true
+	runes := []rune(path)
+	appendKey := func() error {
+		if len(key) == 0 {
+			return fmt.Errorf("invalid path %q: empty key", path)
+		}
+		segments = append(segments, pathSegment{key: string(key), index: -1})
+		key = key[:0]
+		pending = false
+		return nil
+	}
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("invalid path %q: trailing backslash", path)
+			}
+			i++
+			key = append(key, runes[i])
+			pending = true
+		case '.':
+			if pending {
+				if err := appendKey(); err != nil {
+					return nil, err
+				}
+			}
+			pending = true
+		case '[':
+			if len(key) > 0 {
+				if err := appendKey(); err != nil {
+					return nil, err
+				}
+			} else if pending && len(segments) > 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("invalid path %q: unterminated bracket", path)
+			}
+			text := string(runes[i+1 : end])
+			index, err := strconv.Atoi(text)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: invalid index %q", path, text)
+			}
+			segments = append(segments, pathSegment{index: index})
+			i = end
+			pending = false
+		default:
+			key = append(key, c)
+			pending = true
+		}
+	}
+	if pending && (len(key) > 0 || len(segments) > 0) {
+		if err := appendKey(); err != nil {
+			return nil, err
+		}
+	}
+	return segments, nil
+}
+
+// lookupPath returns the value of the source at the path segments, and the path of the value for errors.
+func lookupPath(src reflect.Value, segments []pathSegment) (reflect.Value, string, error) {
+	path := ""
+	for _, segment := range segments {
+		for src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr {
+			if src.IsNil() {
+				return reflect.Value{}, path, pathError(path, fmt.Errorf("not found: nil value"))
+			}
+			src = src.Elem()
+		}
+		var next reflect.Value
+		switch src.Kind() {
+		case reflect.Map:
+			if segment.isIndex() {
+				segment.key = strconv.Itoa(segment.index)
+			}
+			next = mapIndex(src, segment.key)
+		case reflect.Struct:
+			if !segment.isIndex() {
+				if field, found := src.Type().FieldByName(segment.key); found && field.PkgPath == "" {
+					// fields promoted through nil embedded pointers are not found
+					next, _ = src.FieldByIndexErr(field.Index)
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			index := segment.index
+			if !segment.isIndex() {
+				var err error
+				if index, err = strconv.Atoi(segment.key); err != nil || index < 0 {
+					return reflect.Value{}, path, pathError(path, fmt.Errorf("invalid index %q", segment.key))
+				}
+			}
+			if index < src.Len() {
+				next = src.Index(index)
+			}
+		default:
+			return reflect.Value{}, path, pathError(path, badtype("map/struct/slice", src))
+		}
+		if segment.isIndex() {
+			path = joinPath(path, indexSegment(segment.index))
+		} else {
+			path = joinPath(path, segment.key)
+		}
+		if !next.IsValid() {
+			return reflect.Value{}, path, pathError(path, fmt.Errorf("not found"))
+		}
+		src = next
+	}
+	return src, path, nil
+}
+
+// mapIndex returns the value of the key in a map, keys of non-string types are matched by text.
+func mapIndex(src reflect.Value, key string) reflect.Value {
+	if src.Type().Key().Kind() == reflect.String {
+		return src.MapIndex(reflect.ValueOf(key).Convert(src.Type().Key()))
+	}
+	for _, srcKey := range src.MapKeys() {
+		if text, err := stringifyKey(srcKey); err == nil && text == key {
+			return src.MapIndex(srcKey)
+		}
+	}
+	return reflect.Value{}
+}
@@ -0,0 +1,138 @@
+package map2struct
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := map[string][]pathSegment{
+		"":                nil,
+		"a":               {{key: "a", index: -1}},
+		"a.b[1].c":        {{key: "a", index: -1}, {key: "b", index: -1}, {index: 1}, {key: "c", index: -1}},
+		"[0][1]":          {{index: 0}, {index: 1}},
+		`hosts.a\.b\[c\\`: {{key: "hosts", index: -1}, {key: `a.b[c\`, index: -1}},
+	}
+	for path, expect := range cases {
+		segments, err := parsePath(path)
+		if err != nil {
+			t.Error("parse path fail:", err.Error())
+			return
+		} else if len(segments) != len(expect) {
+			t.Errorf("unexpected segments of %q: %v", path, segments)
+			return
+		}
+		for i := range expect {
+			if segments[i] != expect[i] {
+				t.Errorf("unexpected segments of %q: %v", path, segments)
+				return
+			}
+		}
+	}
+	for _, path := range []string{".", "a..b", "a.", "a.[0]", "a[", "a[x]", "a[-1]", `a\`} {
+		if segments, err := parsePath(path); err == nil {
+			t.Errorf("unexpected parse success of %q: %v", path, segments)
+			return
+		}
+	}
+}
+
+func TestUnmarshalPath(t *testing.T) {
+	type Retry struct {
+		Times   int
+		Timeout time.Duration
+	}
+	src := map[string]interface{}{
+		"services": map[string]interface{}{
+			"billing": map[string]interface{}{
+				"retry": map[string]interface{}{"Times": "3", "Timeout": "5s"},
+			},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+		},
+		"hosts": map[string]interface{}{
+			"example.com": "1.2.3.4",
+		},
+		"codes": map[int]string{200: "ok"},
+	}
+	var retry Retry
+	if err := UnmarshalPath(&retry, src, "services.billing.retry"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if retry.Times != 3 || retry.Timeout != 5*time.Second {
+		t.Error("unexpected unmarshal result:", retry)
+		return
+	}
+	var port int
+	for _, path := range []string{"servers[0].port", "servers.0.port"} {
+		port = 0
+		if err := UnmarshalPath(&port, src, path); err != nil {
+			t.Error("unmarshal fail:", err.Error())
+			return
+		} else if port != 80 {
+			t.Error("unexpected unmarshal result:", port)
+			return
+		}
+	}
+	var host string
+	if err := UnmarshalPath(&host, src, `hosts.example\.com`); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if host != "1.2.3.4" {
+		t.Error("unexpected unmarshal result:", host)
+		return
+	}
+	var code string
+	if err := UnmarshalPath(&code, src, "codes.200"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if code != "ok" {
+		t.Error("unexpected unmarshal result:", code)
+		return
+	}
+	// struct source
+	type Config struct {
+		Retry *Retry
+	}
+	if err := UnmarshalPath(&port, &Config{Retry: &Retry{Times: 5}}, "Retry.Times"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if port != 5 {
+		t.Error("unexpected unmarshal result:", port)
+		return
+	}
+	type Embedded struct {
+		*Retry
+	}
+	var pathErr *Error
+	if err := UnmarshalPath(&port, &Embedded{}, "Times"); !errors.As(err, &pathErr) || pathErr.Path != "Times" {
+		t.Error("unexpected unmarshal result of nil embedded pointer:", err)
+		return
+	}
+	// errors
+	cases := map[string]string{
+		"services.billing.missing.x": "services.billing.missing",
+		"servers[1]":                 "servers[1]",
+		"servers.x":                  "servers",
+		"hosts.example.com":          "hosts.example",
+		"services.billing.retry":     "services.billing.retry.Times",
+	}
+	src["services"].(map[string]interface{})["billing"].(map[string]interface{})["retry"] =
+		map[string]interface{}{"Times": "x"}
+	for path, errPath := range cases {
+		err := UnmarshalPath(&retry, src, path)
+		if !errors.As(err, &pathErr) {
+			t.Errorf("unexpected error of %q: %v", path, err)
+			return
+		} else if pathErr.Path != errPath {
+			t.Errorf("unexpected error path of %q: %s", path, pathErr.Path)
+			return
+		}
+	}
+	if err := UnmarshalPath(retry, src, "services"); err == nil {
+		t.Error("unexpected unmarshal success")
+		return
+	}
+}
@@ -0,0 +1,35 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Redacted replaces values of fields tagged `sensitive` or `secret` in marshaled output.
+const Redacted = "******"
+
+// isSensitive returns whether values of the field must not appear in errors and output.
+func isSensitive(tag reflect.StructTag) bool {
+	if _, found := tag.Lookup("sensitive"); found {
+		return true
+	}
+	return isSecret(tag)
+}
+
+// redactError replaces the message of the error of a sensitive value, the path is kept.
+func redactError(dest reflect.Value, err error) error {
+	redacted := fmt.Errorf("invalid value for %s: value redacted", dest.Type())
+	if pathErr, ok := err.(*Error); ok {
+		return &Error{Path: pathErr.Path, Err: redacted}
+	}
+	return redacted
+}
+
+// marshalSensitive masks values of sensitive fields, nil and zero values are kept
+// to show whether the field is set.
+func (decoder *Decoder) marshalSensitive(src reflect.Value, tag reflect.StructTag) (interface{}, error) {
+	if isNil(src) || src.IsZero() {
+		return decoder.marshal(src, tag)
+	}
+	return Redacted, nil
+}
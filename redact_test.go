@@ -0,0 +1,89 @@
+package map2struct
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactSensitive(t *testing.T) {
+	type Credential struct {
+		User     string
+		Password string `sensitive:""`
+	}
+	type TestStruct struct {
+		Enabled    bool          `sensitive:""`
+		Timeout    time.Duration `sensitive:""`
+		Credential Credential    `sensitive:""`
+		Token      *string       `sensitive:""`
+		Name       string
+	}
+	cases := []map[string]interface{}{
+		{"Enabled": "hunter2"},
+		{"Timeout": "hunter2"},
+		{"Credential": map[string]interface{}{"User": map[string]interface{}{"x": "hunter2"}}},
+		{"Token": []string{"hunter2"}},
+	}
+	var a TestStruct
+	for _, src := range cases {
+		err := Unmarshal(&a, src)
+		if err == nil {
+			t.Error("unexpected unmarshal success:", src)
+			return
+		} else if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "redacted") {
+			t.Error("unexpected unmarshal error:", err.Error())
+			return
+		}
+	}
+	// the path of error is kept
+	err := Unmarshal(&a, map[string]interface{}{"Credential": map[string]interface{}{"User": []int{1}}})
+	if pathErr, ok := err.(*Error); !ok || pathErr.Path != "Credential.User" {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+	// not sensitive
+	if err := Unmarshal(&a, map[string]interface{}{"Name": []int{1}}); err == nil || strings.Contains(err.Error(), "redacted") {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+	token := "t0ken"
+	data, err := Marshal(TestStruct{
+		Timeout:    time.Second,
+		Credential: Credential{User: "u", Password: "p"},
+		Token:      &token,
+		Name:       "n",
+	})
+	if err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"Enabled":    false,
+		"Timeout":    Redacted,
+		"Credential": Redacted,
+		"Token":      Redacted,
+		"Name":       "n",
+	}
+	if !reflect.DeepEqual(data, expect) {
+		t.Errorf("unexpected marshal result: expect=%v, actual=%v", expect, data)
+		return
+	}
+	data, err = Marshal(Credential{User: "u", Password: "p"})
+	if err != nil {
+		t.Error("marshal fail:", err.Error())
+		return
+	} else if !reflect.DeepEqual(data, map[string]interface{}{"User": "u", "Password": Redacted}) {
+		t.Error("unexpected marshal result:", data)
+		return
+	}
+}
+
+func TestFactoryErrorRedacted(t *testing.T) {
+	factory := NewGeneralInterfaceFactory(reflect.TypeOf((*error)(nil)).Elem(), "type", nil)
+	_, err := factory.Create(map[string]interface{}{"password": "hunter2"})
+	if err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Error("unexpected create error:", err)
+		return
+	}
+}
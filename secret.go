@@ -0,0 +1,158 @@
+package map2struct
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// SecretResolver resolves secret references of fields tagged `secret`,
+// like "vault:path#key" and "file:/run/secrets/x". References in other string
+// values are resolved if the ResolveSecrets option of the decoder is set.
+type SecretResolver interface {
+	// Resolve returns the secret of the reference without the scheme, like "path#key".
+	Resolve(ref string) (string, error)
+}
+
+var (
+	secretResolvers = make(map[string]SecretResolver)
+)
+
+// RegisterSecretResolver register a secret resolver for references of the scheme.
+// A resolver replaces the previous one registered for the same scheme.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolvers[scheme] = resolver
+}
+
+// FileSecretResolver resolves references as paths of files containing secrets,
+// trailing newlines are trimmed. Relative paths are resolved in the directory,
+// and paths outside the directory are rejected unless the directory is empty.
+func FileSecretResolver(dir string) SecretResolver {
+	return fileSecretResolver(dir)
+}
+
+type fileSecretResolver string
+
+func (dir fileSecretResolver) Resolve(ref string) (string, error) {
+	path := ref
+	if dir != "" {
+		path = filepath.Join(string(dir), ref)
+		if rel, err := filepath.Rel(string(dir), path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("secret file %q is outside %q", ref, string(dir))
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// MemorySecretResolver resolves references from a map of secrets.
+func MemorySecretResolver(secrets map[string]string) SecretResolver {
+	return memorySecretResolver(secrets)
+}
+
+type memorySecretResolver map[string]string
+
+func (secrets memorySecretResolver) Resolve(ref string) (string, error) {
+	if secret, found := secrets[ref]; found {
+		return secret, nil
+	}
+	return "", fmt.Errorf("secret not found: %s", ref)
+}
+
+// isSecret returns whether the field is tagged `secret`.
+func isSecret(tag reflect.StructTag) bool {
+	_, found := tag.Lookup("secret")
+	return found
+}
+
+// resolveSecret resolves the secret reference of a field tagged `secret`.
+// The reference is "scheme:ref" of a registered scheme, or the whole text for the
+// scheme of the tag like `secret:"vault"`. Other text and text of untrusted sources
+// like form values are used as the secret itself.
+func (decoder *Decoder) resolveSecret(src reflect.Value, tag reflect.StructTag) (reflect.Value, error) {
+	if src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return src, nil
+		}
+		src = src.Elem()
+	}
+	if src.Kind() != reflect.String {
+		return src, nil
+	}
+	text := src.String()
+	if len(decoder.Resolvers) > 0 {
+		// references are interpolated, and secrets are not
+		interpolated, err := decoder.interpolate(text, nil)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		text = interpolated
+	}
+	if decoder.literal {
+		return reflect.ValueOf(decoder.escapeItem(text)), nil
+	}
+	scheme, ref := tag.Get("secret"), text
+	if refScheme, refText, ok := secretRef(text); ok {
+		scheme, ref = refScheme, refText
+	}
+	resolver := secretResolvers[scheme]
+	if resolver == nil {
+		if scheme != "" {
+			return reflect.Value{}, fmt.Errorf("unknown secret scheme: %s", scheme)
+		}
+		return reflect.ValueOf(decoder.escapeItem(text)), nil
+	}
+	secret, err := resolver.Resolve(ref)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("resolve secret %s:%s: %s", scheme, ref, err.Error())
+	}
+	return reflect.ValueOf(decoder.escapeItem(secret)), nil
+}
+
+// secretRef splits the reference of a registered scheme like "vault:path#key".
+func secretRef(text string) (string, string, bool) {
+	if index := strings.Index(text, ":"); index > 0 && secretResolvers[text[:index]] != nil {
+		return text[:index], text[index+1:], true
+	}
+	return "", "", false
+}
+
+// unmarshalSecret unmarshals the resolved secret of a field tagged `secret`,
+// errors of resolvers are kept, and others are redacted.
+func (decoder *Decoder) unmarshalSecret(dest, src reflect.Value, tag reflect.StructTag) error {
+	secret, err := decoder.resolveSecret(src, tag)
+	if err != nil {
+		return err
+	}
+	// secrets are not references
+	plain := *decoder
+	plain.ResolveSecrets = false
+	if err := plain.unmarshal(dest, secret, tag); err != nil {
+		return redactError(dest, err)
+	}
+	return nil
+}
+
+// unmarshalSecretRef unmarshals the secret of a string value with a reference of a registered
+// scheme for the ResolveSecrets option, resolved is false for other values.
+func (decoder *Decoder) unmarshalSecretRef(dest, src reflect.Value, tag reflect.StructTag) (resolved bool, err error) {
+	scheme, ref, ok := secretRef(src.String())
+	if !ok {
+		return false, nil
+	}
+	secret, err := secretResolvers[scheme].Resolve(ref)
+	if err != nil {
+		return true, fmt.Errorf("resolve secret %s:%s: %s", scheme, ref, err.Error())
+	}
+	plain := *decoder
+	plain.ResolveSecrets = false
+	if err := plain.unmarshal(dest, reflect.ValueOf(decoder.escapeItem(secret)), tag); err != nil {
+		return true, redactError(dest, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package map2struct
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalSecret(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db"), []byte("s3cret\n"), 0600); err != nil {
+		t.Error("write file fail:", err.Error())
+		return
+	} else if err := os.WriteFile(filepath.Join(dir, "..data"), []byte("kube"), 0600); err != nil {
+		t.Error("write file fail:", err.Error())
+		return
+	}
+	RegisterSecretResolver("file", FileSecretResolver(dir))
+	RegisterSecretResolver("vault", MemorySecretResolver(map[string]string{
+		"db#password": "v@ult",
+		"db#port":     "5432",
+		"db#bad":      "not-a-number",
+	}))
+	defer delete(secretResolvers, "file")
+	defer delete(secretResolvers, "vault")
+	type TestStruct struct {
+		File     string  `secret:""`
+		Vault    *string `secret:""`
+		Default  string  `secret:"vault"`
+		Port     int     `secret:""`
+		Literal  string  `secret:""`
+		Plain    string
+		Password string `secret:""`
+	}
+	src := map[string]interface{}{
+		"File":     "file:db",
+		"Vault":    "vault:db#password",
+		"Default":  "db#password",
+		"Port":     "vault:db#port",
+		"Literal":  "pa:ss",
+		"Plain":    "vault:db#password",
+		"Password": "${SECRET_REF}",
+	}
+	decoder := NewDecoder()
+	decoder.Resolvers = []Resolver{MapResolver(map[string]string{"SECRET_REF": "vault:db#password"})}
+	var a TestStruct
+	if err := decoder.Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if a.File != "s3cret" || a.Vault == nil || *a.Vault != "v@ult" || a.Default != "v@ult" ||
+		a.Port != 5432 || a.Literal != "pa:ss" || a.Plain != "vault:db#password" || a.Password != "v@ult" {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// errors never contain secrets
+	if err := Unmarshal(&a, map[string]interface{}{"Port": "vault:db#bad"}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	} else if strings.Contains(err.Error(), "not-a-number") || !strings.HasPrefix(err.Error(), "Port: ") {
+		t.Error("unexpected unmarshal error:", err.Error())
+		return
+	}
+	errorCases := []map[string]interface{}{
+		{"File": "file:missing"},
+		{"File": "file:../db"},
+		{"Vault": "vault:unknown"},
+	}
+	for _, src := range errorCases {
+		if err := Unmarshal(&a, src); err == nil {
+			t.Error("unexpected unmarshal success:", src)
+			return
+		}
+	}
+	// files like "..data" of Kubernetes secret mounts are inside the directory
+	if err := Unmarshal(&a, map[string]interface{}{"File": "file:..data"}); err != nil || a.File != "kube" {
+		t.Error("unexpected unmarshal result:", a.File, err)
+		return
+	}
+	// references in all string values
+	type Plain struct {
+		Password string
+		Ports    []int
+		Labels   map[string]string
+		Scheme   string
+	}
+	decoder = NewDecoder()
+	decoder.ResolveSecrets = true
+	var c Plain
+	if err := decoder.Unmarshal(&c, map[string]interface{}{
+		"Password": "vault:db#password",
+		"Ports":    []interface{}{"vault:db#port", 80},
+		"Labels":   map[string]interface{}{"db": "file:db"},
+		"Scheme":   "kms:x",
+	}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if c.Password != "v@ult" || len(c.Ports) != 2 || c.Ports[0] != 5432 || c.Labels["db"] != "s3cret" || c.Scheme != "kms:x" {
+		t.Error("unexpected unmarshal result:", c)
+		return
+	}
+	if err := decoder.Unmarshal(&c, map[string]interface{}{"Ports": []interface{}{"vault:db#bad"}}); err == nil {
+		t.Error("unexpected unmarshal success:", c)
+		return
+	} else if strings.Contains(err.Error(), "not-a-number") || !strings.HasPrefix(err.Error(), "Ports[0]: ") {
+		t.Error("unexpected unmarshal error:", err.Error())
+		return
+	}
+	// references in untrusted form values are not resolved
+	var form struct {
+		Password string
+		Secret   string `secret:""`
+		Default  string `secret:"vault"`
+	}
+	if err := decoder.Unmarshal(&form, map[string][]string{
+		"Password": {"vault:db#password"}, "Secret": {"file:db"}, "Default": {"db#password"},
+	}); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if form.Password != "vault:db#password" || form.Secret != "file:db" || form.Default != "db#password" {
+		t.Errorf("unexpected unmarshal result: %+v", form)
+		return
+	}
+	type UnknownScheme struct {
+		Password string `secret:"kms"`
+	}
+	var b UnknownScheme
+	if err := Unmarshal(&b, map[string]interface{}{"Password": "x"}); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+}
@@ -0,0 +1,197 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromEnv reads environment variables with the prefix, see FromEnviron.
+func FromEnv(prefix string, dest interface{}) (*Source, error) {
+	return FromEnviron(os.Environ(), prefix, dest)
+}
+
+// FromEnviron reads variables like "KEY=value" with the prefix, like "APP_DB__HOST" of prefix "APP_".
+// Names without the prefix are split into keys by "__", like "DB__HOST" to "db.host".
+//
+// If the destination is a struct or a pointer to struct, names are matched against
+// its fields, and single underscores are resolved by the struct shape: "DB_PRIMARY_HOST"
+// is "DB.Primary.Host" for nested structs and "DBPrimaryHost" for a field of that name.
+// Field names are matched case-insensitively ignoring underscores, slice indexes are
+// numbers like "SERVERS_0_PORT", and map keys are lower cased. Variables not matching
+// any field are ignored. The positions of values are the names of variables.
+func FromEnviron(environ []string, prefix string, dest interface{}) (*Source, error) {
+	source := newSource("env")
+	var typ reflect.Type
+	if dest != nil {
+		typ = reflect.TypeOf(dest)
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("invalid destination: expect struct but found %T", dest)
+		}
+	}
+	// sorted for stable conflict errors
+	sorted := make([]string, len(environ))
+	copy(sorted, environ)
+	sort.Strings(sorted)
+	for _, item := range sorted {
+		index := strings.IndexByte(item, '=')
+		if index < 0 || !strings.HasPrefix(item[:index], prefix) || index == len(prefix) {
+			continue
+		}
+		name, value := item[:index], item[index+1:]
+		words, boundaries := splitEnvName(name[len(prefix):])
+		var keys []string
+		if typ != nil {
+			if keys = matchEnvKeys(typ, words, boundaries); keys == nil {
+				continue
+			}
+		} else {
+			keys = envKeys(words, boundaries)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if err := setNested(source.Data, keys, value); err != nil {
+			return nil, &Error{Position: Position{File: name}, Err: err}
+		}
+		source.setPosition(strings.Join(keys, "."), Position{File: name})
+	}
+	return source, nil
+}
+
+// splitEnvName splits a name into words by underscores, boundaries mark the words before "__".
+func splitEnvName(name string) ([]string, []bool) {
+	var words []string
+	var boundaries []bool
+	for i, group := range strings.Split(name, "__") {
+		if i > 0 && len(boundaries) > 0 {
+			boundaries[len(boundaries)-1] = true
+		}
+		for _, word := range strings.Split(group, "_") {
+			if word != "" {
+				words = append(words, word)
+				boundaries = append(boundaries, false)
+			}
+		}
+	}
+	return words, boundaries
+}
+
+// envKeys converts words to lower cased keys split by boundaries.
+func envKeys(words []string, boundaries []bool) []string {
+	var keys []string
+	start := 0
+	for i := range words {
+		if boundaries[i] || i == len(words)-1 {
+			keys = append(keys, strings.ToLower(strings.Join(words[start:i+1], "_")))
+			start = i + 1
+		}
+	}
+	return keys
+}
+
+// matchEnvKeys matches words against the type, and returns the keys or nil if not matched.
+func matchEnvKeys(typ reflect.Type, words []string, boundaries []bool) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if len(words) == 0 {
+		return []string{}
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		fields := envFields(typ)
+		// longer field names are preferred
+		for n := len(words); n > 0; n-- {
+			if n > 1 && containsBoundary(boundaries[:n-1]) {
+				continue
+			}
+			name := strings.ToUpper(strings.Join(words[:n], ""))
+			for _, field := range fields {
+				if normalizeEnvName(field.Name) != name {
+					continue
+				}
+				if rest := matchEnvKeys(field.Type, words[n:], boundaries[n:]); rest != nil {
+					return append([]string{field.Name}, rest...)
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if index, err := strconv.Atoi(words[0]); err == nil && index >= 0 {
+			if rest := matchEnvKeys(typ.Elem(), words[1:], boundaries[1:]); rest != nil {
+				return append([]string{words[0]}, rest...)
+			}
+		}
+	case reflect.Map:
+		// keys are the shortest words matching the rest, within a group
+		for n := 1; n <= len(words) && !containsBoundary(boundaries[:n-1]); n++ {
+			key := strings.ToLower(strings.Join(words[:n], "_"))
+			if rest := matchEnvKeys(typ.Elem(), words[n:], boundaries[n:]); rest != nil {
+				return append([]string{key}, rest...)
+			}
+		}
+	case reflect.Interface:
+		return envKeys(words, boundaries)
+	}
+	return nil
+}
+
+// envFields returns the exported fields of a struct type, fields of anonymous structs are promoted.
+func envFields(typ reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, envFields(fieldType)...)
+		} else if field.PkgPath == "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func containsBoundary(boundaries []bool) bool {
+	for _, boundary := range boundaries {
+		if boundary {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeEnvName(name string) string {
+	return strings.ToUpper(strings.Replace(name, "_", "", -1))
+}
+
+// setNested sets the value at the keys, creating maps for missing keys.
+func setNested(data map[string]interface{}, keys []string, value interface{}) error {
+	for i, key := range keys[:len(keys)-1] {
+		switch child := data[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			data[key] = next
+			data = next
+		case map[string]interface{}:
+			data = child
+		default:
+			return fmt.Errorf("conflict key %q: %q is not a map",
+				strings.Join(keys, "."), strings.Join(keys[:i+1], "."))
+		}
+	}
+	key := keys[len(keys)-1]
+	if _, found := data[key]; found {
+		return fmt.Errorf("conflict key %q", strings.Join(keys, "."))
+	}
+	data[key] = value
+	return nil
+}
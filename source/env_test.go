@@ -0,0 +1,122 @@
+package source
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnviron(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int
+	}
+	type Embedded struct {
+		LogLevel string
+	}
+	type Config struct {
+		Embedded
+		DB struct {
+			Primary Server
+			MaxConn int
+		}
+		DBName   string
+		Servers  []Server
+		Labels   map[string]string
+		Backends map[string]Server
+		Timeout  time.Duration
+		Extra    interface{}
+	}
+	environ := []string{
+		"APP_DB_PRIMARY_HOST=db",
+		"APP_DB__PRIMARY__PORT=5432",
+		"APP_DB_MAX_CONN=10",
+		"APP_DB_NAME=app",
+		"APP_SERVERS_0_HOST=a",
+		"APP_SERVERS_1_PORT=81",
+		"APP_LABELS_TEAM_NAME=core",
+		"APP_BACKENDS_MAIN_HOST=m",
+		"APP_TIMEOUT=5s",
+		"APP_LOG_LEVEL=debug",
+		"APP_EXTRA_A__B=x",
+		"APP_UNKNOWN=1",
+		"APP_=1",
+		"OTHER_DB_NAME=x",
+		"INVALID",
+	}
+	source, err := FromEnviron(environ, "APP_", &Config{})
+	if err != nil {
+		t.Error("read env fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"DB": map[string]interface{}{
+			"Primary": map[string]interface{}{"Host": "db", "Port": "5432"},
+			"MaxConn": "10",
+		},
+		"DBName": "app",
+		"Servers": map[string]interface{}{
+			"0": map[string]interface{}{"Host": "a"},
+			"1": map[string]interface{}{"Port": "81"},
+		},
+		"Labels":   map[string]interface{}{"team_name": "core"},
+		"Backends": map[string]interface{}{"main": map[string]interface{}{"Host": "m"}},
+		"Timeout":  "5s",
+		"LogLevel": "debug",
+		"Extra":    map[string]interface{}{"a": map[string]interface{}{"b": "x"}},
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Errorf("unexpected env data:\nexpect=%v\nactual=%v", expect, source.Data)
+		return
+	}
+	var config Config
+	if err := source.Unmarshal(&config); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if config.DB.Primary.Port != 5432 || config.DBName != "app" || len(config.Servers) != 2 ||
+		config.Servers[1].Port != 81 || config.Timeout != 5*time.Second || config.LogLevel != "debug" {
+		t.Error("unexpected unmarshal result:", config)
+		return
+	}
+	// positions are names of variables
+	source, err = FromEnviron([]string{"APP_DB_MAX_CONN=x"}, "APP_", &config)
+	if err != nil {
+		t.Error("read env fail:", err.Error())
+		return
+	} else if err := source.Unmarshal(&config); err == nil || !strings.HasPrefix(err.Error(), "APP_DB_MAX_CONN: DB.MaxConn: ") {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+	// without destination
+	source, err = FromEnviron([]string{"APP_DB__MAX_CONN=10", "APP_NAME=x"}, "APP_", nil)
+	if err != nil {
+		t.Error("read env fail:", err.Error())
+		return
+	} else if !reflect.DeepEqual(source.Data, map[string]interface{}{
+		"db":   map[string]interface{}{"max_conn": "10"},
+		"name": "x",
+	}) {
+		t.Error("unexpected env data:", source.Data)
+		return
+	}
+	// conflict
+	if _, err := FromEnviron([]string{"APP_DB=1", "APP_DB__HOST=x"}, "APP_", nil); err == nil {
+		t.Error("unexpected read success")
+		return
+	}
+	if _, err := FromEnviron(nil, "APP_", 1); err == nil {
+		t.Error("unexpected read success")
+		return
+	}
+	os.Setenv("MAP2STRUCT_TEST_DB_NAME", "env")
+	defer os.Unsetenv("MAP2STRUCT_TEST_DB_NAME")
+	if source, err := FromEnv("MAP2STRUCT_TEST_", &config); err != nil {
+		t.Error("read env fail:", err.Error())
+		return
+	} else if source.Data["DBName"] != "env" {
+		t.Error("unexpected env data:", source.Data)
+		return
+	}
+}
@@ -0,0 +1,272 @@
+package source
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	map2struct "github.com/yangchenxing/go-map2struct"
+)
+
+var (
+	// flagDecoder is the decoder of flags, for separators of fields without tags
+	flagDecoder = map2struct.NewDecoder()
+
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Flags are the flags bound to fields of a struct by BindFlags.
+type Flags struct {
+	flagSet *flag.FlagSet
+	values  map[string]*flagValue
+}
+
+// BindFlags registers a flag for each leaf field of the struct, like "-db.max-conn" for
+// the field DB.MaxConn. Names of fields are converted to kebab case, nested structs
+// are joined by dots, and fields of anonymous structs are promoted. Texts of flags are
+// parsed by the rules of Unmarshal, like durations, times, percentages and slices.
+// Slice and map flags can be repeated. The `usage` tag is the usage of the flag, and
+// defaults are the current values of fields except sensitive ones.
+// Slices and maps of structs, interfaces, functions and channels are not bound.
+func BindFlags(flagSet *flag.FlagSet, dest interface{}) (*Flags, error) {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid destination: expect non-nil pointer to struct but found %T", dest)
+	}
+	flags := &Flags{flagSet: flagSet, values: make(map[string]*flagValue)}
+	if err := flags.bind(value.Elem(), nil, ""); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (flags *Flags) bind(value reflect.Value, keys []string, prefix string) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldValue := value.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			if fieldValue.IsNil() {
+				fieldValue = reflect.Zero(fieldType)
+			} else {
+				fieldValue = fieldValue.Elem()
+			}
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			// fields of anonymous field are promoted
+			if err := flags.bind(fieldValue, keys, prefix); err != nil {
+				return err
+			}
+			continue
+		} else if field.PkgPath != "" {
+			continue
+		}
+		fieldKeys := append(keys[:len(keys):len(keys)], field.Name)
+		name := prefix + kebabCase(field.Name)
+		switch {
+		case isFlagStruct(fieldType):
+			if err := flags.bind(fieldValue, fieldKeys, name+"."); err != nil {
+				return err
+			}
+		case isFlagLeaf(fieldType):
+			if flags.flagSet.Lookup(name) != nil {
+				return fmt.Errorf("flag redefined: %s", name)
+			}
+			flagValue := &flagValue{typ: field.Type, tag: field.Tag, keys: fieldKeys}
+			if _, sensitive := field.Tag.Lookup("sensitive"); !sensitive && !isSecretTag(field.Tag) {
+				flagValue.defaultText = flagText(fieldValue, field.Tag)
+			}
+			flagValue.repeated = fieldType.Kind() == reflect.Map ||
+				fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() != reflect.Uint8
+			usage := field.Tag.Get("usage")
+			if usage == "" {
+				usage = strings.Join(fieldKeys, ".")
+			}
+			flags.flagSet.Var(flagValue, name, usage)
+			flags.values[name] = flagValue
+		}
+	}
+	return nil
+}
+
+func isSecretTag(tag reflect.StructTag) bool {
+	_, found := tag.Lookup("secret")
+	return found
+}
+
+// isFlagStruct returns whether fields of the struct type are bound as flags.
+func isFlagStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != timeType && map2struct.LookupConverter(typ) == nil &&
+		!reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// isFlagLeaf returns whether values of the type can be parsed from flags.
+func isFlagLeaf(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Struct:
+		return !isFlagStruct(typ)
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Uintptr,
+		reflect.Complex64, reflect.Complex128:
+		return false
+	case reflect.Slice, reflect.Array:
+		return typ.Elem().Kind() == reflect.Uint8 || isFlagLeaf(typ.Elem()) && !isFlagStruct(typ.Elem())
+	case reflect.Map:
+		return isFlagLeaf(typ.Key()) && isFlagLeaf(typ.Elem()) && !isFlagStruct(typ.Elem())
+	case reflect.Ptr:
+		return isFlagLeaf(typ.Elem())
+	}
+	return true
+}
+
+// flagSeparators returns the separators of items and keys of maps of the field,
+// like the `sep` and `kvsep` tags.
+func flagSeparators(tag reflect.StructTag) (string, string) {
+	sep, kvsep := flagDecoder.Separator, flagDecoder.KeyValueSeparator
+	if text, found := tag.Lookup("sep"); found {
+		sep = text
+	}
+	if text, found := tag.Lookup("kvsep"); found {
+		kvsep = text
+	}
+	return sep, kvsep
+}
+
+// flagText returns the text of a field value for defaults of flags.
+func flagText(value reflect.Value, tag reflect.StructTag) string {
+	if !value.IsValid() || value.IsZero() {
+		return ""
+	}
+	marshaled, err := map2struct.Marshal(value.Interface())
+	if err != nil {
+		return fmt.Sprint(value.Interface())
+	}
+	sep, kvsep := flagSeparators(tag)
+	switch marshaled := marshaled.(type) {
+	case []interface{}:
+		texts := make([]string, len(marshaled))
+		for i, item := range marshaled {
+			texts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(texts, sep)
+	case map[string]interface{}:
+		texts := make([]string, 0, len(marshaled))
+		for key, item := range marshaled {
+			texts = append(texts, fmt.Sprintf("%s%s%v", key, kvsep, item))
+		}
+		sort.Strings(texts)
+		return strings.Join(texts, sep)
+	}
+	return fmt.Sprint(marshaled)
+}
+
+// Source returns the values of flags set in the command line, which can be
+// unmarshaled over values of other sources. Positions of values are the flags.
+func (flags *Flags) Source() *Source {
+	source := newSource("flags")
+	flags.flagSet.Visit(func(f *flag.Flag) {
+		value, found := flags.values[f.Name]
+		if !found {
+			return
+		}
+		// keys of fields never conflict
+		_ = setNested(source.Data, value.keys, value.value())
+		source.setPosition(strings.Join(value.keys, "."), Position{File: "-" + f.Name})
+	})
+	return source
+}
+
+// flagValue is a flag.Value of a field, texts are validated by unmarshaling to the field type.
+type flagValue struct {
+	typ         reflect.Type
+	tag         reflect.StructTag
+	keys        []string
+	repeated    bool
+	defaultText string
+	texts       []string
+}
+
+func (value *flagValue) String() string {
+	if value == nil {
+		return ""
+	} else if len(value.texts) > 0 {
+		return strings.Join(value.texts, ",")
+	}
+	return value.defaultText
+}
+
+func (value *flagValue) Set(text string) error {
+	texts := []string{text}
+	if value.repeated {
+		texts = append(value.texts[:len(value.texts):len(value.texts)], text)
+	}
+	candidate := &flagValue{typ: value.typ, tag: value.tag, repeated: value.repeated, texts: texts}
+	// texts are validated with the tag like `layout:"2006-01-02"`, and secret
+	// references are left to the final unmarshaling
+	if !isSecretTag(value.tag) {
+		field := reflect.StructField{Name: "Value", Type: value.typ, Tag: value.tag}
+		dest := reflect.New(reflect.StructOf([]reflect.StructField{field}))
+		if err := map2struct.Unmarshal(dest.Interface(), map[string]interface{}{"Value": candidate.value()}); err != nil {
+			if pathErr, ok := err.(*map2struct.Error); ok && pathErr.Path == "Value" {
+				return pathErr.Err
+			}
+			return err
+		}
+	}
+	value.texts = texts
+	return nil
+}
+
+// IsBoolFlag allows bool flags without values like "-verbose".
+func (value *flagValue) IsBoolFlag() bool {
+	typ := value.typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Bool
+}
+
+// value returns the text of the flag, or the list of texts of repeated slice flags.
+// Texts of repeated map flags are joined by the separator of the field.
+func (value *flagValue) value() interface{} {
+	if len(value.texts) == 1 {
+		return value.texts[0]
+	}
+	typ := value.typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Map {
+		sep, _ := flagSeparators(value.tag)
+		return strings.Join(value.texts, sep)
+	}
+	list := make([]interface{}, len(value.texts))
+	for i, text := range value.texts {
+		list[i] = text
+	}
+	return list
+}
+
+// kebabCase converts names like "MaxConn" and "HTTPServer" to "max-conn" and "http-server".
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) && (!unicode.IsUpper(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])) && runes[i-1] != '_' {
+			builder.WriteByte('-')
+		}
+		if c == '_' {
+			c = '-'
+		}
+		builder.WriteRune(unicode.ToLower(c))
+	}
+	return builder.String()
+}
@@ -0,0 +1,155 @@
+package source
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testFlagBase struct {
+	Verbose bool `usage:"verbose output"`
+}
+
+type testFlagConfig struct {
+	testFlagBase
+	Name    string
+	Timeout time.Duration
+	Ratio   float64
+	Tags    []string
+	Labels  map[string]int
+	Started time.Time
+	DB      struct {
+		Host    string
+		MaxConn int
+	}
+	Password string `sensitive:""`
+	Servers  []testServer
+	Handler  func()
+}
+
+func TestBindFlags(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+	config := testFlagConfig{Name: "app", Password: "secret"}
+	config.DB.Host = "localhost"
+	config.DB.MaxConn = 10
+	flags, err := BindFlags(flagSet, &config)
+	if err != nil {
+		t.Error("bind flags fail:", err.Error())
+		return
+	}
+	for name, expect := range map[string]string{
+		"verbose": "", "name": "app", "db.host": "localhost", "db.max-conn": "10", "password": "",
+	} {
+		if f := flagSet.Lookup(name); f == nil || f.DefValue != expect {
+			t.Errorf("unexpected flag %q: %v", name, f)
+			return
+		}
+	}
+	for _, name := range []string{"servers", "handler", "db"} {
+		if flagSet.Lookup(name) != nil {
+			t.Errorf("unexpected flag %q", name)
+			return
+		}
+	}
+	if usage := flagSet.Lookup("verbose").Usage; usage != "verbose output" {
+		t.Error("unexpected usage:", usage)
+		return
+	}
+	args := []string{"-verbose", "-timeout", "1m30s", "-ratio", "50%", "-tags", "a,b", "-tags", "c",
+		"-labels", "x=1", "-labels", "y=2", "-started", "2020-01-02T03:04:05Z", "-db.max-conn", "20"}
+	if err := flagSet.Parse(args); err != nil {
+		t.Error("parse flags fail:", err.Error())
+		return
+	}
+	if err := flags.Source().Unmarshal(&config); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := testFlagConfig{
+		testFlagBase: testFlagBase{Verbose: true},
+		Name:         "app",
+		Timeout:      90 * time.Second,
+		Ratio:        0.5,
+		Tags:         []string{"a,b", "c"},
+		Labels:       map[string]int{"x": 1, "y": 2},
+		Started:      time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Password:     "secret",
+	}
+	expect.DB.Host = "localhost"
+	expect.DB.MaxConn = 20
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("unexpected config: %+v", config)
+		return
+	}
+	if position, _ := flags.Source().Position("DB.MaxConn"); position.String() != "-db.max-conn" {
+		t.Error("unexpected position:", position)
+		return
+	}
+	// invalid values are rejected by the flag set
+	if err := flagSet.Parse([]string{"-db.max-conn", "x"}); err == nil || !strings.Contains(err.Error(), "db.max-conn") {
+		t.Error("unexpected parse result:", err)
+		return
+	}
+	if _, err := BindFlags(flagSet, &config); err == nil {
+		t.Error("unexpected bind success")
+		return
+	}
+	// values are validated with tags of fields
+	var tagged struct {
+		Date time.Time `layout:"2006-01-02"`
+	}
+	flagSet = flag.NewFlagSet("tagged", flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+	if flags, err = BindFlags(flagSet, &tagged); err != nil {
+		t.Error("bind flags fail:", err.Error())
+		return
+	} else if err := flagSet.Parse([]string{"-date=2020-01-02"}); err != nil {
+		t.Error("parse flags fail:", err.Error())
+		return
+	} else if err := flags.Source().Unmarshal(&tagged); err != nil || !tagged.Date.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected unmarshal result:", tagged.Date, err)
+		return
+	}
+	// repeated map flags and defaults are joined by separators of fields
+	separated := struct {
+		Tags map[string]string `sep:";" kvsep:":"`
+	}{Tags: map[string]string{"x": "0", "y": "1"}}
+	flagSet = flag.NewFlagSet("separated", flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+	if flags, err = BindFlags(flagSet, &separated); err != nil {
+		t.Error("bind flags fail:", err.Error())
+		return
+	} else if f := flagSet.Lookup("tags"); f.DefValue != "x:0;y:1" {
+		t.Error("unexpected default:", f.DefValue)
+		return
+	} else if err := flagSet.Parse([]string{"-tags", "a:1", "-tags", "b:2"}); err != nil {
+		t.Error("parse flags fail:", err.Error())
+		return
+	} else if err := flags.Source().Unmarshal(&separated); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if expect := map[string]string{"a": "1", "b": "2", "x": "0", "y": "1"}; !reflect.DeepEqual(separated.Tags, expect) {
+		t.Error("unexpected tags:", separated.Tags)
+		return
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	cases := map[string]string{
+		"Name":       "name",
+		"MaxConn":    "max-conn",
+		"HTTPServer": "http-server",
+		"DB":         "db",
+		"Max_Conn":   "max-conn",
+	}
+	for name, expect := range cases {
+		if actual := kebabCase(name); actual != expect {
+			t.Errorf("unexpected kebab case of %q: %q", name, actual)
+			return
+		}
+	}
+}
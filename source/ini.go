@@ -0,0 +1,68 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FromINI reads an INI file. Keys before any section are in the root, and keys of
+// a section like "[db]" are in the map of the section name. Lines starting with
+// ";" or "#" are comments, keys and values are separated by "=" or ":", values
+// quoted by double quotes are unquoted, and other values are trimmed.
+// All values are text, and later keys replace earlier ones.
+func FromINI(name string, reader io.Reader) (*Source, error) {
+	source := newSource(name)
+	source.setPosition("", Position{File: name, Line: 1, Column: 1})
+	section, sectionPath := source.Data, ""
+	scanner := bufio.NewScanner(reader)
+	for number := 1; scanner.Scan(); number++ {
+		raw := scanner.Text()
+		text := strings.TrimSpace(raw)
+		offset := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		position := Position{File: name, Line: number, Column: utf8.RuneCountInString(raw[:offset]) + 1}
+		switch {
+		case text == "" || text[0] == ';' || text[0] == '#':
+			continue
+		case text[0] == '[':
+			if !strings.HasSuffix(text, "]") {
+				return nil, &Error{Position: position, Err: fmt.Errorf("unterminated section: %s", text)}
+			}
+			sectionPath = strings.TrimSpace(text[1 : len(text)-1])
+			if sectionPath == "" {
+				return nil, &Error{Position: position, Err: fmt.Errorf("empty section name")}
+			}
+			existing, ok := source.Data[sectionPath].(map[string]interface{})
+			if !ok {
+				existing = make(map[string]interface{})
+				source.Data[sectionPath] = existing
+			}
+			section = existing
+			source.setPosition(sectionPath, position)
+			continue
+		}
+		index := strings.IndexAny(text, "=:")
+		if index <= 0 {
+			return nil, &Error{Position: position, Err: fmt.Errorf("expect key and value: %s", text)}
+		}
+		key := strings.TrimSpace(text[:index])
+		value := strings.TrimSpace(text[index+1:])
+		valueOffset := offset + index + 1 + len(text[index+1:]) - len(strings.TrimLeft(text[index+1:], " \t"))
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, &Error{Position: position, Err: fmt.Errorf("invalid quoted value: %s", value)}
+			}
+			value = unquoted
+		}
+		section[key] = value
+		source.setPosition(joinKey(sectionPath, key), Position{File: name, Line: number, Column: utf8.RuneCountInString(raw[:valueOffset]) + 1})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
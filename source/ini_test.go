@@ -0,0 +1,102 @@
+package source
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromINI(t *testing.T) {
+	text := `; comment
+name = app
+[db]
+host: localhost
+  port = 5432
+password = "a;b\t"
+[db]
+user = root
+`
+	source, err := FromINI("config.ini", strings.NewReader(text))
+	if err != nil {
+		t.Error("read ini fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"name": "app",
+		"db": map[string]interface{}{
+			"host":     "localhost",
+			"port":     "5432",
+			"password": "a;b\t",
+			"user":     "root",
+		},
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Error("unexpected ini data:", source.Data)
+		return
+	}
+	if position, _ := source.Position("db.port"); position != (Position{File: "config.ini", Line: 5, Column: 10}) {
+		t.Error("unexpected position:", position)
+		return
+	}
+	for _, text := range []string{"[db", "[]", "key"} {
+		if _, err := FromINI("config.ini", strings.NewReader(text)); err == nil {
+			t.Errorf("unexpected read success: %q", text)
+			return
+		}
+	}
+}
+
+func TestFromProperties(t *testing.T) {
+	text := `# comment
+! comment
+db.host = localhost
+db.port:5432
+db.name   app
+path = C:\\dir\\file
+list = a,\
+       b,\
+       c
+key\ with\ spaces = \u00e9\t
+empty
+`
+	source, err := FromProperties("app.properties", strings.NewReader(text))
+	if err != nil {
+		t.Error("read properties fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"db.host":         "localhost",
+		"db.port":         "5432",
+		"db.name":         "app",
+		"path":            `C:\dir\file`,
+		"list":            "a,b,c",
+		"key with spaces": "\u00e9\t",
+		"empty":           "",
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Error("unexpected properties data:", source.Data)
+		return
+	}
+	source, err = FromProperties("app.properties", strings.NewReader("DB.Host = a\nDB.Port = x\n"))
+	if err != nil {
+		t.Error("read properties fail:", err.Error())
+		return
+	}
+	var config struct {
+		DB struct {
+			Host string
+			Port int
+		}
+	}
+	if err := source.Unmarshal(&config); err == nil {
+		t.Error("unexpected unmarshal success:", config)
+		return
+	} else if !strings.HasPrefix(err.Error(), "app.properties:2:1: DB.Port: ") {
+		t.Error("unexpected unmarshal error:", err.Error())
+		return
+	}
+	if _, err := FromProperties("app.properties", strings.NewReader(`a = \u00`)); err == nil {
+		t.Error("unexpected read success")
+		return
+	}
+}
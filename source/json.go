@@ -0,0 +1,134 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FromJSON reads a JSON object. Numbers are int64 if they are integers fitting int64,
+// uint64 if they are larger integers fitting uint64, and float64 otherwise.
+func FromJSON(name string, reader io.Reader) (*Source, error) {
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	source := newSource(name)
+	parser := &jsonParser{
+		source:  source,
+		index:   newLineIndex(name, text),
+		decoder: json.NewDecoder(bytes.NewReader(text)),
+	}
+	parser.decoder.UseNumber()
+	value, err := parser.parseValue("")
+	if err != nil {
+		return nil, err
+	}
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, parser.index.errorf(0, "expect object but found %T", value)
+	}
+	if _, err := parser.decoder.Token(); err != io.EOF {
+		return nil, parser.index.errorf(int(parser.decoder.InputOffset()), "unexpected data after object")
+	}
+	source.Data = data
+	return source, nil
+}
+
+type jsonParser struct {
+	source  *Source
+	index   *lineIndex
+	decoder *json.Decoder
+}
+
+// valueOffset returns the offset of the next value, after the separators of the previous token.
+func (parser *jsonParser) valueOffset() int {
+	offset := int(parser.decoder.InputOffset())
+	for offset < len(parser.index.text) {
+		switch parser.index.text[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func (parser *jsonParser) parseValue(path string) (interface{}, error) {
+	offset := parser.valueOffset()
+	token, err := parser.decoder.Token()
+	if err == io.EOF {
+		return nil, parser.index.errorf(offset, "unexpected end of JSON")
+	} else if err != nil {
+		return nil, parser.index.errorf(offset, "%s", err.Error())
+	}
+	parser.source.setPosition(path, parser.index.position(offset))
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			return parser.parseObject(path)
+		}
+		return parser.parseArray(path)
+	case json.Number:
+		value, err := jsonNumber(token)
+		if err != nil {
+			return nil, parser.index.errorf(offset, "%s", err.Error())
+		}
+		return value, nil
+	}
+	return token, nil
+}
+
+func (parser *jsonParser) parseObject(path string) (interface{}, error) {
+	data := make(map[string]interface{})
+	for parser.decoder.More() {
+		offset := parser.valueOffset()
+		token, err := parser.decoder.Token()
+		if err != nil {
+			return nil, parser.index.errorf(offset, "%s", err.Error())
+		}
+		key := token.(string)
+		if _, found := data[key]; found {
+			return nil, parser.index.errorf(offset, "duplicate key %q", key)
+		}
+		if data[key], err = parser.parseValue(joinKey(path, key)); err != nil {
+			return nil, err
+		}
+	}
+	// closing delimiter
+	if _, err := parser.decoder.Token(); err != nil {
+		return nil, parser.index.errorf(parser.valueOffset(), "%s", err.Error())
+	}
+	return data, nil
+}
+
+func (parser *jsonParser) parseArray(path string) (interface{}, error) {
+	list := make([]interface{}, 0)
+	for parser.decoder.More() {
+		value, err := parser.parseValue(joinIndex(path, len(list)))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	if _, err := parser.decoder.Token(); err != nil {
+		return nil, parser.index.errorf(parser.valueOffset(), "%s", err.Error())
+	}
+	return list, nil
+}
+
+func jsonNumber(number json.Number) (interface{}, error) {
+	if value, err := strconv.ParseInt(string(number), 10, 64); err == nil {
+		return value, nil
+	} else if value, err := strconv.ParseUint(string(number), 10, 64); err == nil {
+		return value, nil
+	}
+	value, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", number)
+	}
+	return value, nil
+}
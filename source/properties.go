@@ -0,0 +1,120 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FromProperties reads a Java properties file. Keys are kept flat like "db.host",
+// which are unmarshaled to nested structs by the KeySeparator of map2struct.
+// Lines ending with an odd number of backslashes are continued, and escapes
+// like "\t", "\n" and "\uXXXX" are supported. All values are text.
+func FromProperties(name string, reader io.Reader) (*Source, error) {
+	source := newSource(name)
+	source.setPosition("", Position{File: name, Line: 1, Column: 1})
+	scanner := bufio.NewScanner(reader)
+	for number := 0; scanner.Scan(); {
+		number++
+		raw := scanner.Text()
+		text := strings.TrimLeft(raw, " \t\f")
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+		position := Position{File: name, Line: number, Column: utf8.RuneCountInString(raw[:len(raw)-len(text)]) + 1}
+		// continuation lines
+		for trailingBackslashes(text)%2 == 1 {
+			text = text[:len(text)-1]
+			if !scanner.Scan() {
+				break
+			}
+			number++
+			text += strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+		key, value, err := splitProperty(text)
+		if err != nil {
+			return nil, &Error{Position: position, Err: err}
+		}
+		source.Data[key] = value
+		source.setPosition(key, position)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func trailingBackslashes(text string) int {
+	count := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		count++
+	}
+	return count
+}
+
+// splitProperty splits a line into the key and the value, separated by the first
+// unescaped "=", ":" or whitespace.
+func splitProperty(text string) (string, string, error) {
+	end := len(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+		} else if strings.IndexByte("=: \t\f", text[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rest := strings.TrimLeft(text[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	key, err := unescapeProperty(text[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(text string) (string, error) {
+	if !strings.Contains(text, `\`) {
+		return text, nil
+	}
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 >= len(text) {
+			builder.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch c := text[i]; c {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u':
+			if i+5 > len(text) {
+				return "", fmt.Errorf("invalid unicode escape: %s", text[i-1:])
+			}
+			code, err := strconv.ParseUint(text[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape: %s", text[i-1:i+5])
+			}
+			builder.WriteRune(rune(code))
+			i += 4
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,205 @@
+// Package source reads configuration files of JSON, YAML, TOML, INI and Java properties
+// to the map[string]interface{} shape expected by map2struct, and keeps the positions
+// of values so unmarshaling errors can be reported like "config.yaml:42:7".
+package source
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	map2struct "github.com/yangchenxing/go-map2struct"
+)
+
+// Position is the position of a value in a file, lines and columns start from 1.
+// Positions of values not in files have only names, like environment variables, and
+// positions of some syntax errors have no columns.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (position Position) String() string {
+	if position.Line == 0 {
+		// positions without lines, like names of environment variables
+		return position.File
+	} else if position.Column == 0 {
+		return fmt.Sprintf("%s:%d", position.File, position.Line)
+	} else if position.File == "" {
+		return fmt.Sprintf("%d:%d", position.Line, position.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", position.File, position.Line, position.Column)
+}
+
+// Error is an error with the position in the file.
+type Error struct {
+	Position Position
+	Err      error
+}
+
+func (err *Error) Error() string {
+	return err.Position.String() + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Source is the data read from a file with positions of its values.
+type Source struct {
+	// Name is the name of the file used in positions.
+	Name string
+
+	// Data is the data of the file, keys are strings, numbers are int64 or float64, and
+	// integers of JSON larger than int64 are uint64.
+	Data map[string]interface{}
+
+	positions map[string]Position
+}
+
+func newSource(name string) *Source {
+	return &Source{
+		Name:      name,
+		Data:      make(map[string]interface{}),
+		positions: make(map[string]Position),
+	}
+}
+
+// Position returns the position of the value at the path, like "servers.0.port" or
+// "Servers[0].Port" of map2struct errors. The position of the nearest parent is
+// returned if the value is not found, like values missing in the file.
+func (source *Source) Position(path string) (Position, bool) {
+	for key := canonicalPath(path); ; {
+		if position, found := source.positions[key]; found {
+			return position, true
+		}
+		index := strings.LastIndex(key, ".")
+		if index < 0 {
+			break
+		}
+		key = key[:index]
+	}
+	position, found := source.positions[""]
+	return position, found
+}
+
+// Paths returns the paths of all values with positions, sorted.
+func (source *Source) Paths() []string {
+	paths := make([]string, 0, len(source.positions))
+	for path := range source.positions {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Unmarshal unmarshals the data to the destination, errors are annotated with positions.
+func (source *Source) Unmarshal(dest interface{}) error {
+	return source.Annotate(map2struct.Unmarshal(dest, source.Data))
+}
+
+// UnmarshalOrigins unmarshals the data like Unmarshal, and returns the origins of decoded fields.
+// Names of source origins are the positions of values, like "config.yaml:3:7" or "APP_DB_HOST".
+func (source *Source) UnmarshalOrigins(dest interface{}) (map[string]map2struct.Origin, error) {
+	origins, err := map2struct.UnmarshalOrigins(dest, source.Data)
+	for path, origin := range origins {
+		if origin.Kind != map2struct.OriginSource {
+			continue
+		} else if position, found := source.Position(path); found {
+			origin.Name = position.String()
+			origins[path] = origin
+		}
+	}
+	return origins, source.Annotate(err)
+}
+
+// Annotate adds the position of the value to a map2struct error of unmarshaling the data,
+// like the errors of Decoder.Unmarshal with a custom decoder.
+func (source *Source) Annotate(err error) error {
+	var pathErr *map2struct.Error
+	if err == nil || !errors.As(err, &pathErr) {
+		return err
+	}
+	if position, found := source.Position(pathErr.Path); found {
+		return &Error{Position: position, Err: err}
+	}
+	return err
+}
+
+func (source *Source) setPosition(path string, position Position) {
+	source.positions[canonicalPath(path)] = position
+}
+
+// canonicalPath converts brackets of a path to dotted segments, like "a[0][k]" to "a.0.k".
+func canonicalPath(path string) string {
+	if !strings.Contains(path, "[") {
+		return path
+	}
+	var builder strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '[':
+			if builder.Len() > 0 {
+				builder.WriteByte('.')
+			}
+		case ']':
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func joinIndex(parent string, index int) string {
+	return joinKey(parent, fmt.Sprint(index))
+}
+
+// lineIndex converts offsets of a text to positions.
+type lineIndex struct {
+	name  string
+	text  []byte
+	lines []int
+}
+
+func newLineIndex(name string, text []byte) *lineIndex {
+	index := &lineIndex{name: name, text: text, lines: []int{0}}
+	for i, c := range text {
+		if c == '\n' {
+			index.lines = append(index.lines, i+1)
+		}
+	}
+	return index
+}
+
+func (index *lineIndex) position(offset int) Position {
+	line := sort.Search(len(index.lines), func(i int) bool { return index.lines[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+	start := index.lines[line]
+	if offset > len(index.text) {
+		offset = len(index.text)
+	}
+	return Position{
+		File:   index.name,
+		Line:   line + 1,
+		Column: utf8.RuneCount(index.text[start:offset]) + 1,
+	}
+}
+
+func (index *lineIndex) errorf(offset int, format string, args ...interface{}) error {
+	return &Error{Position: index.position(offset), Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,158 @@
+package source
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	map2struct "github.com/yangchenxing/go-map2struct"
+)
+
+type testServer struct {
+	Host string
+	Port int
+}
+
+type testConfig struct {
+	Name    string
+	Servers []testServer
+	Labels  map[string]int
+}
+
+func TestSourceUnmarshal(t *testing.T) {
+	text := `{
+  "Name": "app",
+  "Servers": [
+    {"Host": "a", "Port": 80},
+    {"Host": "b", "Port": "x"}
+  ],
+  "Labels": {"a.b": 1}
+}`
+	source, err := FromJSON("config.json", strings.NewReader(text))
+	if err != nil {
+		t.Error("read json fail:", err.Error())
+		return
+	}
+	var config testConfig
+	err = source.Unmarshal(&config)
+	var sourceErr *Error
+	var pathErr *map2struct.Error
+	if !errors.As(err, &sourceErr) || !errors.As(err, &pathErr) {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	} else if !strings.HasPrefix(err.Error(), "config.json:5:27: Servers[1].Port: ") {
+		t.Error("unexpected unmarshal error:", err.Error())
+		return
+	}
+	// map keys containing dots
+	source.Data["Servers"] = []interface{}{}
+	source.Data["Labels"] = map[string]interface{}{"a.b": "y"}
+	if err := source.Unmarshal(&config); err == nil || !strings.HasPrefix(err.Error(), "config.json:7:21: Labels[a.b]: ") {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+	// errors without path
+	if err := source.Annotate(errors.New("x")); err.Error() != "x" || source.Annotate(nil) != nil {
+		t.Error("unexpected annotate result:", err)
+		return
+	}
+	if paths := source.Paths(); len(paths) != 10 || paths[0] != "Labels" {
+		t.Error("unexpected paths:", paths)
+		return
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	source, err := FromJSON("config.json", strings.NewReader(`{"a": [1, 1.5, 18446744073709551615, -12345678901234567890, null, true, "s"]}`))
+	if err != nil {
+		t.Error("read json fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"a": []interface{}{int64(1), 1.5, uint64(18446744073709551615), -12345678901234567890.0, nil, true, "s"},
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Error("unexpected json data:", source.Data)
+		return
+	}
+	// integers larger than int64 are kept exactly
+	var config struct{ ID uint64 }
+	if source, err := FromJSON("config.json", strings.NewReader(`{"ID": 18446744073709551615}`)); err != nil {
+		t.Error("read json fail:", err.Error())
+		return
+	} else if err := source.Unmarshal(&config); err != nil || config.ID != math.MaxUint64 {
+		t.Error("unexpected unmarshal result:", config.ID, err)
+		return
+	}
+	cases := map[string]string{
+		`[1]`:              "config.json:1:1",
+		`{"a": 1, "a": 2}`: "config.json:1:10",
+		"{\n\"a\": }":      "config.json:2:",
+		`{"a": 1} 2`:       "config.json:1:",
+		`{"a": 1e999}`:     "config.json:1:7",
+	}
+	for text, prefix := range cases {
+		if _, err := FromJSON("config.json", strings.NewReader(text)); err == nil {
+			t.Errorf("unexpected read success: %q", text)
+			return
+		} else if !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("unexpected read error of %q: %s", text, err.Error())
+			return
+		}
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	cases := map[string]string{
+		"":           "",
+		"a.b":        "a.b",
+		"a[0].b[k]":  "a.0.b.k",
+		"[0][1]":     "0.1",
+		"Servers[1]": "Servers.1",
+	}
+	for path, expect := range cases {
+		if actual := canonicalPath(path); actual != expect {
+			t.Errorf("unexpected canonical path of %q: %q", path, actual)
+			return
+		}
+	}
+}
+
+func TestSourceUnmarshalOrigins(t *testing.T) {
+	text := `{
+  "Name": "app",
+  "Servers": [{"Host": "a", "Port": 80}]
+}`
+	source, err := FromJSON("config.json", strings.NewReader(text))
+	if err != nil {
+		t.Error("read json fail:", err.Error())
+		return
+	}
+	var config struct {
+		testConfig
+		Timeout string `default:"1s"`
+	}
+	origins, err := source.UnmarshalOrigins(&config)
+	if err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := map[string]string{
+		"Name":            "source config.json:2:11",
+		"Servers[0].Port": "source config.json:3:37",
+		"Timeout":         "default 1s",
+	}
+	for path, origin := range expect {
+		if actual := origins[path].String(); actual != origin {
+			t.Errorf("unexpected origin of %q: %s", path, actual)
+			return
+		}
+	}
+	source.Data["Name"] = []interface{}{}
+	if _, err := source.UnmarshalOrigins(&config); err == nil || !strings.HasPrefix(err.Error(), "config.json:2:11: Name: ") {
+		t.Error("unexpected unmarshal error:", err)
+		return
+	}
+}
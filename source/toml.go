@@ -0,0 +1,168 @@
+package source
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// FromTOML reads a TOML document by github.com/pelletier/go-toml/v2. Offset date-times
+// are time.Time, and local date-times, dates and times are kept as text.
+func FromTOML(name string, reader io.Reader) (*Source, error) {
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	source := newSource(name)
+	if err := toml.Unmarshal(text, &source.Data); err != nil {
+		// errors of redefined keys and tables have no positions
+		position := Position{File: name}
+		var decodeError *toml.DecodeError
+		if errors.As(err, &decodeError) {
+			position.Line, position.Column = decodeError.Position()
+		}
+		return nil, &Error{Position: position, Err: errors.New(strings.TrimPrefix(err.Error(), "toml: "))}
+	}
+	source.Data = tomlValue(source.Data).(map[string]interface{})
+	source.setPosition("", Position{File: name, Line: 1, Column: 1})
+	builder := &tomlBuilder{source: source, index: newLineIndex(name, text), text: text, arrays: make(map[string]int)}
+	builder.parser.Reset(text)
+	for builder.parser.NextExpression() {
+		builder.expression(builder.parser.Expression())
+	}
+	return source, builder.parser.Error()
+}
+
+// tomlValue converts local date-times, dates and times to text.
+func tomlValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = tomlValue(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = tomlValue(item)
+		}
+	case toml.LocalDate:
+		return value.String()
+	case toml.LocalTime:
+		return value.String()
+	case toml.LocalDateTime:
+		return value.String()
+	}
+	return value
+}
+
+// tomlBuilder records positions of the expressions of a document decoded successfully.
+type tomlBuilder struct {
+	source *Source
+	index  *lineIndex
+	text   []byte
+	parser unstable.Parser
+
+	// path is the path of the table of the last header, and arrays are the
+	// numbers of tables in arrays of tables.
+	path   string
+	arrays map[string]int
+}
+
+func (builder *tomlBuilder) expression(node *unstable.Node) {
+	switch node.Kind {
+	case unstable.Table, unstable.ArrayTable:
+		// headers are positioned at the brackets
+		offset, _ := builder.offset(tomlFirstKey(node))
+		for offset > 0 && strings.ContainsRune("[ \t", rune(builder.text[offset-1])) {
+			offset--
+		}
+		position := builder.index.position(offset)
+		path := builder.key(node.Key(), "", position)
+		if node.Kind == unstable.ArrayTable {
+			count := builder.arrays[path]
+			builder.arrays[path]++
+			path = joinIndex(path, count)
+		}
+		builder.source.setPosition(path, position)
+		builder.path = path
+	case unstable.KeyValue:
+		builder.keyValue(builder.path, node)
+	}
+}
+
+// key resolves the path of the dotted key under the parent. Keys of arrays of tables
+// are resolved to their last tables, and new tables are positioned at the position.
+func (builder *tomlBuilder) key(keys unstable.Iterator, parent string, position Position) string {
+	path := parent
+	for keys.Next() {
+		if path != parent {
+			if count, found := builder.arrays[path]; found {
+				path = joinIndex(path, count-1)
+			}
+		}
+		path = joinKey(path, string(keys.Node().Data))
+		builder.setPosition(path, position)
+	}
+	return path
+}
+
+func (builder *tomlBuilder) keyValue(parent string, node *unstable.Node) {
+	position := builder.position(tomlFirstKey(node))
+	path := builder.key(node.Key(), parent, position)
+	builder.value(path, node.Value(), position)
+}
+
+func (builder *tomlBuilder) value(path string, node *unstable.Node, position Position) {
+	if offset, found := builder.offset(node); found {
+		position = builder.index.position(offset)
+	}
+	builder.source.setPosition(path, position)
+	switch node.Kind {
+	case unstable.Array:
+		for i, items := 0, node.Children(); items.Next(); i++ {
+			builder.value(joinIndex(path, i), items.Node(), position)
+		}
+	case unstable.InlineTable:
+		for items := node.Children(); items.Next(); {
+			builder.keyValue(path, items.Node())
+		}
+	}
+}
+
+func tomlFirstKey(node *unstable.Node) *unstable.Node {
+	keys := node.Key()
+	keys.Next()
+	return keys.Node()
+}
+
+// setPosition positions tables at their first definitions.
+func (builder *tomlBuilder) setPosition(path string, position Position) {
+	if _, found := builder.source.positions[path]; !found {
+		builder.source.setPosition(path, position)
+	}
+}
+
+func (builder *tomlBuilder) position(node *unstable.Node) Position {
+	offset, _ := builder.offset(node)
+	return builder.index.position(offset)
+}
+
+// offset returns the offset of the node in the text. Data of some nodes refers to the
+// text without raw ranges, and containers are found by their first children.
+func (builder *tomlBuilder) offset(node *unstable.Node) (int, bool) {
+	if node.Raw.Length > 0 {
+		return int(node.Raw.Offset), true
+	} else if len(node.Data) > 0 && len(builder.text) > 0 {
+		start := reflect.ValueOf(builder.text).Pointer()
+		if data := reflect.ValueOf(node.Data).Pointer(); data >= start && data < start+uintptr(len(builder.text)) {
+			return int(data - start), true
+		}
+	}
+	if child := node.Child(); child != nil {
+		return builder.offset(child)
+	}
+	return 0, false
+}
@@ -0,0 +1,117 @@
+package source
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromTOML(t *testing.T) {
+	text := `# comment
+title = "TOML \"example\" \u00e9"
+literal = 'C:\path'
+multi = """
+a \
+  b"""
+raw = '''
+x\n'''
+int = 1_000
+hex = 0xff
+neg = -3
+float = 6.5e-1
+inf = -inf
+bool = true
+odt = 1979-05-27 07:32:00Z
+ld = 1979-05-27
+array = [
+  1, 2, # comment
+  3,
+]
+inline = { a = 1, b.c = "x" }
+site."google.com" = true
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+[products.size]
+width = 2
+`
+	source, err := FromTOML("config.toml", strings.NewReader(text))
+	if err != nil {
+		t.Error("read toml fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"title":   "TOML \"example\" \u00e9",
+		"literal": `C:\path`,
+		"multi":   "a b",
+		"raw":     `x\n`,
+		"int":     int64(1000),
+		"hex":     int64(255),
+		"neg":     int64(-3),
+		"float":   0.65,
+		"inf":     math.Inf(-1),
+		"bool":    true,
+		"odt":     time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"ld":      "1979-05-27",
+		"array":   []interface{}{int64(1), int64(2), int64(3)},
+		"inline":  map[string]interface{}{"a": int64(1), "b": map[string]interface{}{"c": "x"}},
+		"site":    map[string]interface{}{"google.com": true},
+		"servers": map[string]interface{}{
+			"alpha": map[string]interface{}{"ip": "10.0.0.1"},
+		},
+		"products": []interface{}{
+			map[string]interface{}{"name": "Hammer"},
+			map[string]interface{}{"name": "Nail", "size": map[string]interface{}{"width": int64(2)}},
+		},
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Errorf("unexpected toml data:\nexpect=%v\nactual=%v", expect, source.Data)
+		return
+	}
+	positions := map[string]Position{
+		"array[2]":               {File: "config.toml", Line: 19, Column: 3},
+		"servers.alpha.ip":       {File: "config.toml", Line: 25, Column: 6},
+		"products[1].size.width": {File: "config.toml", Line: 33, Column: 9},
+		"products[0].missing":    {File: "config.toml", Line: 27, Column: 1},
+	}
+	for path, expect := range positions {
+		if position, found := source.Position(path); !found || position != expect {
+			t.Errorf("unexpected position of %s: %v", path, position)
+			return
+		}
+	}
+}
+
+func TestFromTOMLError(t *testing.T) {
+	cases := map[string]string{
+		"a = 1\na = 2":         "config.toml",
+		"[a]\n[a]":             "config.toml",
+		"a = 1\n[a]":           "config.toml",
+		"a = [1\n":             "config.toml:2:1",
+		"a = \"x":              "config.toml:1:7",
+		"a = 1 b":              "config.toml:1:7",
+		"a = 01":               "config.toml:1:5",
+		"a = \"\\q\"":          "config.toml:1:7",
+		"a = [1]\n[[a]]":       "config.toml",
+		"= 1":                  "config.toml:1:1",
+		"a = 1979-13-01":       "config.toml:1:5",
+		"a = { b = 1, b = 2 }": "config.toml",
+	}
+	for text, prefix := range cases {
+		if _, err := FromTOML("config.toml", strings.NewReader(text)); err == nil {
+			t.Errorf("unexpected read success: %q", text)
+			return
+		} else if !strings.HasPrefix(err.Error(), prefix+": ") {
+			t.Errorf("unexpected read error of %q: %s", text, err.Error())
+			return
+		}
+	}
+}
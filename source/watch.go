@@ -0,0 +1,254 @@
+package source
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yangchenxing/go-map2struct"
+)
+
+// DefaultWatchInterval is the default polling interval of watchers.
+const DefaultWatchInterval = time.Second
+
+// Validator is implemented by configurations validating themselves after reloading.
+type Validator interface {
+	Validate() error
+}
+
+// Watcher reloads a configuration file when it changes. Each reload reads the file,
+// unmarshals it to a new instance and validates it, and the instance is swapped in
+// only if all steps succeed. Failed reloads keep the previous instance.
+//
+// Changes are notified by inotify on Linux, and polled by the interval otherwise
+// or if notifications are not available.
+type Watcher[T any] struct {
+	// Path is the path of the file.
+	Path string
+
+	// Read reads the file to a source, like FromYAML.
+	Read func(name string, reader io.Reader) (*Source, error)
+
+	// Interval is the polling interval. Default is DefaultWatchInterval.
+	Interval time.Duration
+
+	// Validate validates new instances. Instances implementing Validator are also validated by it.
+	Validate func(*T) error
+
+	// OnError is called with errors of reloads in background, like invalid files.
+	OnError func(error)
+
+	// Decoder unmarshals the sources with its options, like TimeLayouts and Resolvers.
+	// The default decoder is used if it is nil.
+	Decoder *map2struct.Decoder
+
+	value       atomic.Pointer[T]
+	reloading   sync.Mutex
+	data        map[string]interface{}
+	info        os.FileInfo
+	mutex       sync.Mutex
+	subscribers []func(old, new *T)
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+// NewWatcher creates a watcher of the file read by the function, like FromYAML.
+func NewWatcher[T any](path string, read func(name string, reader io.Reader) (*Source, error)) *Watcher[T] {
+	return &Watcher[T]{Path: path, Read: read, Interval: DefaultWatchInterval}
+}
+
+// Load returns the current instance, nil before the first successful reload.
+// Instances must not be modified since they are shared.
+func (watcher *Watcher[T]) Load() *T {
+	return watcher.value.Load()
+}
+
+// Subscribe adds a function called with the previous and new instance after each successful reload
+// changing the file data. The previous instance is nil for the first reload.
+func (watcher *Watcher[T]) Subscribe(subscriber func(old, new *T)) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	watcher.subscribers = append(watcher.subscribers, subscriber)
+}
+
+// Start loads the file and starts watching it in background. Errors of the first load are returned.
+func (watcher *Watcher[T]) Start() error {
+	watcher.mutex.Lock()
+	started := watcher.done != nil
+	watcher.mutex.Unlock()
+	if started {
+		return errors.New("watcher is started")
+	}
+	// notifications are started before loading, so changes after loading are not missed
+	notifier, err := newNotifier(watcher.Path)
+	if err != nil {
+		notifier = nil
+	}
+	if err := watcher.Reload(); err != nil {
+		if notifier != nil {
+			notifier.Close()
+		}
+		return err
+	}
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	if watcher.done != nil {
+		if notifier != nil {
+			notifier.Close()
+		}
+		return errors.New("watcher is started")
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	watcher.done, watcher.stopped = done, stopped
+	go watcher.run(notifier, done, stopped)
+	return nil
+}
+
+// Close stops watching the file.
+func (watcher *Watcher[T]) Close() error {
+	watcher.mutex.Lock()
+	done, stopped := watcher.done, watcher.stopped
+	watcher.done, watcher.stopped = nil, nil
+	watcher.mutex.Unlock()
+	if done == nil {
+		return nil
+	}
+	close(done)
+	<-stopped
+	return nil
+}
+
+// Reload reloads the file. The instance is not swapped if the file data is not changed.
+// Subscribers are called after the reload, so they can reload the file again.
+func (watcher *Watcher[T]) Reload() error {
+	old, instance, err := watcher.reload()
+	if err != nil || instance == nil {
+		return err
+	}
+	watcher.mutex.Lock()
+	subscribers := watcher.subscribers
+	watcher.mutex.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber(old, instance)
+	}
+	return nil
+}
+
+// reload reloads the file, and returns the previous and new instance if it is swapped.
+func (watcher *Watcher[T]) reload() (*T, *T, error) {
+	watcher.reloading.Lock()
+	defer watcher.reloading.Unlock()
+	file, err := os.Open(watcher.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	// errors are reported once for each change of the file
+	watcher.info = info
+	source, err := watcher.Read(watcher.Path, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	old := watcher.value.Load()
+	if old != nil && reflect.DeepEqual(source.Data, watcher.data) {
+		return nil, nil, nil
+	}
+	instance := new(T)
+	if watcher.Decoder != nil {
+		err = source.Annotate(watcher.Decoder.Unmarshal(instance, source.Data))
+	} else {
+		err = source.Unmarshal(instance)
+	}
+	if err != nil {
+		return nil, nil, err
+	} else if err := watcher.validate(instance); err != nil {
+		return nil, nil, err
+	}
+	watcher.data = source.Data
+	watcher.value.Store(instance)
+	return old, instance, nil
+}
+
+func (watcher *Watcher[T]) validate(instance *T) error {
+	if validator, ok := interface{}(instance).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+	if watcher.Validate != nil {
+		return watcher.Validate(instance)
+	}
+	return nil
+}
+
+// changed returns whether the file is replaced, or its modification time or size is changed since the last reload.
+func (watcher *Watcher[T]) changed() bool {
+	info, err := os.Stat(watcher.Path)
+	if err != nil {
+		// reloaded to report the error
+		return true
+	}
+	watcher.reloading.Lock()
+	defer watcher.reloading.Unlock()
+	return watcher.info == nil || !os.SameFile(info, watcher.info) ||
+		!info.ModTime().Equal(watcher.info.ModTime()) || info.Size() != watcher.info.Size()
+}
+
+func (watcher *Watcher[T]) run(notifier notifier, done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	var events <-chan struct{}
+	var ticker *time.Ticker
+	var ticks <-chan time.Time
+	poll := func() {
+		interval := watcher.Interval
+		if interval <= 0 {
+			interval = DefaultWatchInterval
+		}
+		ticker = time.NewTicker(interval)
+		ticks = ticker.C
+	}
+	if notifier != nil {
+		defer notifier.Close()
+		events = notifier.Events()
+	} else {
+		poll()
+	}
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case _, ok := <-events:
+			if !ok {
+				// notifications failed, fallback to polling
+				events = nil
+				poll()
+				continue
+			}
+		case <-ticks:
+		}
+		if !watcher.changed() {
+			continue
+		} else if err := watcher.Reload(); err != nil && watcher.OnError != nil {
+			watcher.OnError(err)
+		}
+	}
+}
+
+// notifier notifies changes in the directory of a file.
+type notifier interface {
+	Events() <-chan struct{}
+	Close() error
+}
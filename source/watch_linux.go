@@ -0,0 +1,62 @@
+//go:build linux
+
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// inotifyNotifier notifies changes in the directory of a file by inotify.
+// The directory is watched so files replaced by renaming are notified.
+type inotifyNotifier struct {
+	file   *os.File
+	events chan struct{}
+}
+
+func newNotifier(path string) (notifier, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE |
+		syscall.IN_DELETE | syscall.IN_ATTRIB)
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	// non-blocking descriptors are read by the runtime poller, so Close stops reading
+	notifier := &inotifyNotifier{file: os.NewFile(uintptr(fd), "inotify"), events: make(chan struct{}, 1)}
+	go notifier.read()
+	return notifier, nil
+}
+
+func (notifier *inotifyNotifier) read() {
+	defer close(notifier.events)
+	buffer := make([]byte, 4096)
+	for {
+		if count, err := notifier.file.Read(buffer); err != nil {
+			return
+		} else if count < syscall.SizeofInotifyEvent {
+			continue
+		}
+		// events are coalesced, the watcher checks the file itself
+		select {
+		case notifier.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (notifier *inotifyNotifier) Events() <-chan struct{} {
+	return notifier.events
+}
+
+func (notifier *inotifyNotifier) Close() error {
+	return notifier.file.Close()
+}
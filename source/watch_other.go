@@ -0,0 +1,9 @@
+//go:build !linux
+
+package source
+
+import "errors"
+
+func newNotifier(path string) (notifier, error) {
+	return nil, errors.New("file notifications are not supported")
+}
@@ -0,0 +1,159 @@
+package source
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yangchenxing/go-map2struct"
+)
+
+type testWatchConfig struct {
+	Name string
+	Port int
+}
+
+func (config *testWatchConfig) Validate() error {
+	if config.Port < 0 {
+		return errors.New("negative port")
+	}
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Error("create temp dir fail:", err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	write := func(text string) {
+		// replaced by renaming like editors and deployments
+		if err := ioutil.WriteFile(path+".tmp", []byte(text), 0644); err != nil {
+			t.Fatal("write file fail:", err.Error())
+		} else if err := os.Rename(path+".tmp", path); err != nil {
+			t.Fatal("rename file fail:", err.Error())
+		}
+	}
+	write(`{"Name": "a", "Port": 1}`)
+	watcher := NewWatcher[testWatchConfig](path, FromJSON)
+	watcher.Interval = 10 * time.Millisecond
+	watcher.Validate = func(config *testWatchConfig) error {
+		if config.Name == "" {
+			return errors.New("empty name")
+		}
+		return nil
+	}
+	changes := make(chan [2]*testWatchConfig, 10)
+	watcher.Subscribe(func(old, new *testWatchConfig) { changes <- [2]*testWatchConfig{old, new} })
+	errs := make(chan error, 10)
+	watcher.OnError = func(err error) { errs <- err }
+	if err := watcher.Start(); err != nil {
+		t.Error("start watcher fail:", err.Error())
+		return
+	}
+	defer watcher.Close()
+	if change := <-changes; change[0] != nil || *change[1] != (testWatchConfig{"a", 1}) || watcher.Load() != change[1] {
+		t.Error("unexpected first load:", change)
+		return
+	}
+	first := watcher.Load()
+	write(`{"Name": "b", "Port": 22}`)
+	select {
+	case change := <-changes:
+		if change[0] != first || *change[1] != (testWatchConfig{"b", 22}) || watcher.Load() != change[1] {
+			t.Error("unexpected reload:", change)
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("reload timeout")
+		return
+	}
+	second := watcher.Load()
+	for _, text := range []string{`{"Name": "c", "Port": "x"}`, `{"Name": "c", "Port": -1}`, `{"Name": "", "Port": 333}`, `{`} {
+		write(text)
+		select {
+		case err := <-errs:
+			if watcher.Load() != second {
+				t.Error("unexpected swap after error:", err)
+				return
+			}
+		case change := <-changes:
+			t.Error("unexpected reload:", change)
+			return
+		case <-time.After(5 * time.Second):
+			t.Errorf("reload timeout of %q", text)
+			return
+		}
+	}
+	// unchanged data is not swapped
+	write(`{"Port": 22, "Name": "b"}`)
+	if err := watcher.Reload(); err != nil || watcher.Load() != second || len(changes) != 0 {
+		t.Error("unexpected reload result:", err)
+		return
+	}
+	watcher.Close()
+	if err := watcher.Reload(); err != nil {
+		t.Error("reload fail:", err.Error())
+		return
+	}
+	os.Remove(path)
+	if err := watcher.Reload(); err == nil || !strings.Contains(err.Error(), "config.json") {
+		t.Error("unexpected reload result:", err)
+		return
+	}
+}
+
+func TestWatcherDecoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Error("create temp dir fail:", err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"Name": "${NAME}", "Port": 1}`), 0644); err != nil {
+		t.Error("write file fail:", err.Error())
+		return
+	}
+	watcher := NewWatcher[testWatchConfig](path, FromJSON)
+	watcher.Decoder = map2struct.NewDecoder()
+	watcher.Decoder.Resolvers = []map2struct.Resolver{map2struct.MapResolver(map[string]string{"NAME": "resolved"})}
+	// subscribers can reload the file
+	reloaded := make(chan error, 1)
+	watcher.Subscribe(func(old, new *testWatchConfig) { reloaded <- watcher.Reload() })
+	if err := watcher.Start(); err != nil {
+		t.Error("start watcher fail:", err.Error())
+		return
+	}
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Error("reload in subscriber fail:", err.Error())
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("reload in subscriber timeout")
+		return
+	}
+	if config := watcher.Load(); config == nil || config.Name != "resolved" {
+		t.Error("unexpected config:", config)
+		return
+	}
+	// closed concurrently
+	closed := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { closed <- watcher.Close() }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-closed; err != nil {
+			t.Error("close fail:", err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	map2struct "github.com/yangchenxing/go-map2struct"
+	"gopkg.in/yaml.v3"
+)
+
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line ([0-9]+): (.*)$`)
+
+// FromYAML reads YAML documents of mappings by gopkg.in/yaml.v3. Anchors, aliases and
+// merge keys like "<<: *defaults" are expanded, and multiple documents are merged in
+// order like layers of map2struct.Merge. Timestamps are kept as text, and the other
+// scalars are resolved by yaml.v3. Columns of syntax errors are not reported.
+func FromYAML(name string, reader io.Reader) (*Source, error) {
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	source := newSource(name)
+	source.setPosition("", Position{File: name, Line: 1, Column: 1})
+	// aliases are expanded to a limited number of values, so small documents cannot expand to huge data
+	builder := &yamlBuilder{source: source, budget: 4*len(text) + 1024}
+	decoder := yaml.NewDecoder(bytes.NewReader(text))
+	for first := true; ; first = false {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err == io.EOF {
+			return source, nil
+		} else if err != nil {
+			return nil, yamlError(name, err)
+		} else if len(document.Content) == 0 {
+			continue
+		}
+		node := document.Content[0]
+		if first {
+			source.setPosition("", builder.position(node))
+		}
+		value, err := builder.value("", node)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := value.(map[string]interface{})
+		if value == nil {
+			continue
+		} else if !ok {
+			return nil, builder.errorf(node, "expect mapping but found %s", node.ShortTag())
+		} else if first {
+			source.Data = data
+		} else if source.Data, err = yamlDocumentMerger.Merge(source.Data, data); err != nil {
+			return nil, builder.errorf(node, "merge document: %s", err.Error())
+		}
+	}
+}
+
+// yamlDocumentMerger merges documents without splitting keys, and replaces sequences.
+var yamlDocumentMerger = func() *map2struct.Decoder {
+	decoder := map2struct.NewDecoder()
+	decoder.KeySeparator = ""
+	decoder.SliceMerge = map2struct.MergeReplace
+	return decoder
+}()
+
+// yamlError converts errors of yaml.v3 like "yaml: line 2: ..." to errors with positions.
+func yamlError(name string, err error) error {
+	if match := yamlErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return &Error{Position: Position{File: name, Line: line}, Err: errors.New(match[2])}
+	}
+	return &Error{Position: Position{File: name}, Err: errors.New(strings.TrimPrefix(err.Error(), "yaml: "))}
+}
+
+// yamlBuilder builds data of YAML nodes, and records their positions.
+type yamlBuilder struct {
+	source *Source
+	budget int
+	// expanding are the anchors being expanded, for detecting recursive aliases
+	expanding []*yaml.Node
+}
+
+func (builder *yamlBuilder) position(node *yaml.Node) Position {
+	return Position{File: builder.source.Name, Line: node.Line, Column: node.Column}
+}
+
+func (builder *yamlBuilder) errorf(node *yaml.Node, format string, args ...interface{}) error {
+	return &Error{Position: builder.position(node), Err: fmt.Errorf(format, args...)}
+}
+
+// resolve returns the anchor of aliases.
+func (builder *yamlBuilder) resolve(node *yaml.Node) (*yaml.Node, error) {
+	for node.Kind == yaml.AliasNode {
+		for _, expanding := range builder.expanding {
+			if expanding == node.Alias {
+				return nil, builder.errorf(node, "recursive alias: %s", node.Value)
+			}
+		}
+		node = node.Alias
+	}
+	return node, nil
+}
+
+func (builder *yamlBuilder) value(path string, node *yaml.Node) (interface{}, error) {
+	if builder.budget--; builder.budget < 0 {
+		return nil, builder.errorf(node, "too many values expanded by aliases")
+	}
+	// values of aliases are positioned at the aliases
+	builder.source.setPosition(path, builder.position(node))
+	if node.Kind == yaml.AliasNode {
+		anchor, err := builder.resolve(node)
+		if err != nil {
+			return nil, err
+		}
+		builder.expanding = append(builder.expanding, anchor)
+		defer func() { builder.expanding = builder.expanding[:len(builder.expanding)-1] }()
+		node = anchor
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return builder.scalar(node)
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			var err error
+			if list[i], err = builder.value(joinIndex(path, i), item); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case yaml.MappingNode:
+		data := make(map[string]interface{}, len(node.Content)/2)
+		if err := builder.mapping(path, node, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, builder.errorf(node, "unexpected node: %s", node.ShortTag())
+}
+
+// mapping builds the entries of the mapping to the data. Keys merged by "<<" are
+// overridden by keys of the mapping, and keys of earlier merged mappings take precedence.
+func (builder *yamlBuilder) mapping(path string, node *yaml.Node, data map[string]interface{}) error {
+	defined := make(map[string]bool, len(node.Content)/2)
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, err := builder.resolve(node.Content[i])
+		if err != nil {
+			return err
+		} else if keyNode.Kind != yaml.ScalarNode {
+			return builder.errorf(node.Content[i], "unsupported key: %s", keyNode.ShortTag())
+		} else if keyNode.ShortTag() == "!!merge" {
+			merges = append(merges, node.Content[i+1])
+			continue
+		} else if defined[keyNode.Value] {
+			return builder.errorf(node.Content[i], "duplicate key %q", keyNode.Value)
+		}
+		defined[keyNode.Value] = true
+	}
+	for i := len(merges) - 1; i >= 0; i-- {
+		if err := builder.merge(path, merges[i], data); err != nil {
+			return err
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, _ := builder.resolve(node.Content[i])
+		if keyNode.ShortTag() == "!!merge" {
+			continue
+		}
+		value, err := builder.value(joinKey(path, keyNode.Value), node.Content[i+1])
+		if err != nil {
+			return err
+		}
+		data[keyNode.Value] = value
+	}
+	return nil
+}
+
+// merge builds the mappings of a merge key like "<<: *defaults" or "<<: [*a, *b]" to the data.
+func (builder *yamlBuilder) merge(path string, node *yaml.Node, data map[string]interface{}) error {
+	anchor, err := builder.resolve(node)
+	if err != nil {
+		return err
+	}
+	sources := []*yaml.Node{node}
+	if anchor.Kind == yaml.SequenceNode {
+		sources = anchor.Content
+	}
+	for i := len(sources) - 1; i >= 0; i-- {
+		source, err := builder.resolve(sources[i])
+		if err != nil {
+			return err
+		} else if source.Kind != yaml.MappingNode {
+			return builder.errorf(sources[i], "expect mapping to merge but found %s", source.ShortTag())
+		}
+		builder.expanding = append(builder.expanding, source)
+		err = builder.mapping(path, source, data)
+		builder.expanding = builder.expanding[:len(builder.expanding)-1]
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (builder *yamlBuilder) scalar(node *yaml.Node) (interface{}, error) {
+	if node.ShortTag() == "!!timestamp" && node.Style&yaml.TaggedStyle == 0 {
+		return node.Value, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, builder.errorf(node, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if number, ok := value.(int); ok {
+		return int64(number), nil
+	}
+	return value, nil
+}
@@ -0,0 +1,155 @@
+package source
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromYAML(t *testing.T) {
+	text := `# config
+---
+# comment
+name: "app # not comment"
+version: 1.5
+count: 0x10
+enabled: true
+empty:
+nothing: ~
+inf: -.inf
+quoted: 'it''s'
+url: http://example.com/a#b
+services:
+  billing:
+    retry: 3 # comment
+    hosts: [a, "b", {c: 1}]
+servers:
+- host: a
+  port: 80
+- host: b
+  tags:
+    - x
+    - - y
+      - z
+-
+  nested: true
+- plain
+script: |
+  echo a
+
+  echo b
+folded: >-
+  a
+  b
+
+  c
+keep: |+
+  x
+
+"quoted key": v
+-dash: 1
+defaults: &defaults
+  adapter: postgres
+  host: localhost
+development:
+  <<: *defaults
+  host: dev
+list: [1,
+  2]
+...
+---
+version: 2.5
+`
+	source, err := FromYAML("config.yaml", strings.NewReader(text))
+	if err != nil {
+		t.Error("read yaml fail:", err.Error())
+		return
+	}
+	expect := map[string]interface{}{
+		"name":    "app # not comment",
+		"version": 2.5,
+		"count":   int64(16),
+		"enabled": true,
+		"empty":   nil,
+		"nothing": nil,
+		"inf":     math.Inf(-1),
+		"quoted":  "it's",
+		"url":     "http://example.com/a#b",
+		"services": map[string]interface{}{
+			"billing": map[string]interface{}{
+				"retry": int64(3),
+				"hosts": []interface{}{"a", "b", map[string]interface{}{"c": int64(1)}},
+			},
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "port": int64(80)},
+			map[string]interface{}{"host": "b", "tags": []interface{}{"x", []interface{}{"y", "z"}}},
+			map[string]interface{}{"nested": true},
+			"plain",
+		},
+		"script":      "echo a\n\necho b\n",
+		"folded":      "a b\nc",
+		"keep":        "x\n\n",
+		"quoted key":  "v",
+		"-dash":       int64(1),
+		"defaults":    map[string]interface{}{"adapter": "postgres", "host": "localhost"},
+		"development": map[string]interface{}{"adapter": "postgres", "host": "dev"},
+		"list":        []interface{}{int64(1), int64(2)},
+	}
+	if !reflect.DeepEqual(source.Data, expect) {
+		t.Errorf("unexpected yaml data:\nexpect=%v\nactual=%v", expect, source.Data)
+		return
+	}
+	positions := map[string]Position{
+		"services.billing.retry":   {File: "config.yaml", Line: 15, Column: 12},
+		"services.billing.hosts.2": {File: "config.yaml", Line: 16, Column: 21},
+		"servers[1].tags[1][0]":    {File: "config.yaml", Line: 23, Column: 9},
+		"servers[0].missing":       {File: "config.yaml", Line: 18, Column: 3},
+		"development.adapter":      {File: "config.yaml", Line: 43, Column: 12},
+		"development.host":         {File: "config.yaml", Line: 47, Column: 9},
+		"list[1]":                  {File: "config.yaml", Line: 49, Column: 3},
+		"version":                  {File: "config.yaml", Line: 52, Column: 10},
+	}
+	for path, expect := range positions {
+		if position, found := source.Position(path); !found || position != expect {
+			t.Errorf("unexpected position of %s: %v", path, position)
+			return
+		}
+	}
+}
+
+func TestFromYAMLError(t *testing.T) {
+	cases := map[string]string{
+		"a: 1\n  b: 2":   "config.yaml:2",
+		"a: 1\na: 2":     "config.yaml:2:1",
+		"a:\n\t- 1":      "config.yaml:2",
+		"a: [1, 2":       "config.yaml:1",
+		"a: &x [*x]":     "config.yaml:1:8",
+		"a: *x":          "config.yaml",
+		"a: \"\\q\"":     "config.yaml",
+		"- 1\n- 2":       "config.yaml:1:1",
+		"a: 1\n---\n- 2": "config.yaml:3:1",
+		"<<: 1":          "config.yaml:1:5",
+		"a: !!int x":     "config.yaml:1:4",
+		"{a: 1}: 2":      "config.yaml:1:1",
+		"a: 'x' y":       "config.yaml",
+	}
+	for text, prefix := range cases {
+		if _, err := FromYAML("config.yaml", strings.NewReader(text)); err == nil {
+			t.Errorf("unexpected read success: %q", text)
+			return
+		} else if !strings.HasPrefix(err.Error(), prefix+": ") {
+			t.Errorf("unexpected read error of %q: %s", text, err.Error())
+			return
+		}
+	}
+	source, err := FromYAML("config.yaml", strings.NewReader("# empty\n"))
+	if err != nil {
+		t.Error("read yaml fail:", err.Error())
+		return
+	} else if len(source.Data) != 0 {
+		t.Error("unexpected yaml data:", source.Data)
+		return
+	}
+}
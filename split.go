@@ -0,0 +1,152 @@
+package map2struct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// splitText splits text by the separator into at most n items, n < 0 means no limit.
+// Items can be quoted by double quotes to contain separators, and characters
+// escaped by backslash are kept literally. Spaces around unquoted text are trimmed.
+func splitText(text, sep string, n int) ([]string, error) {
+	rawItems, err := splitRaw(text, sep, n)
+	if err != nil || rawItems == nil {
+		return nil, err
+	}
+	items := make([]string, len(rawItems))
+	for i, rawItem := range rawItems {
+		items[i] = unquoteItem(rawItem)
+	}
+	return items, nil
+}
+
+// splitRaw splits text by the separator outside quotes and escapes,
+// the quotes and escapes are kept in the items.
+func splitRaw(text, sep string, n int) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var items []string
+	runes := []rune(text)
+	sepRunes := []rune(sep)
+	start := 0
+	quoted := false
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && len(sepRunes) > 0 && hasRunePrefix(runes[i:], sepRunes) && (n < 0 || len(items) < n-1):
+			items = append(items, string(runes[start:i]))
+			i += len(sepRunes) - 1
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in text: %s", text)
+	}
+	return append(items, string(runes[start:])), nil
+}
+
+// unquoteItem removes quotes and escapes of a raw item, and trims spaces around unquoted text.
+func unquoteItem(rawItem string) string {
+	var item []rune
+	protected := 0
+	quoted := false
+	runes := []rune(rawItem)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '\\' && i+1 < len(runes):
+			i++
+			item = append(item, runes[i])
+			protected = len(item)
+		case c == '"':
+			quoted = !quoted
+			protected = len(item)
+		case quoted:
+			item = append(item, c)
+			protected = len(item)
+		case len(item) == 0 && unicode.IsSpace(c):
+			// leading spaces
+		default:
+			item = append(item, c)
+		}
+	}
+	return string(item[:protected]) + strings.TrimRightFunc(string(item[protected:]), unicode.IsSpace)
+}
+
+func hasRunePrefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i, c := range prefix {
+		if runes[i] != c {
+			return false
+		}
+	}
+	return true
+}
+
+func (decoder *Decoder) separator(tag reflect.StructTag) string {
+	if sep, found := tag.Lookup("sep"); found {
+		return sep
+	}
+	return decoder.Separator
+}
+
+func (decoder *Decoder) keyValueSeparator(tag reflect.StructTag) string {
+	if sep, found := tag.Lookup("kvsep"); found {
+		return sep
+	}
+	return decoder.KeyValueSeparator
+}
+
+// splitList splits text to a list for unmarshaling slices and sets.
+func (decoder *Decoder) splitList(text string, tag reflect.StructTag) (reflect.Value, error) {
+	sep := decoder.separator(tag)
+	if sep == "" {
+		return reflect.Value{}, fmt.Errorf("expect array/slice but found text: separator is disabled")
+	}
+	items, err := splitText(text, sep, -1)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	list := make([]interface{}, len(items))
+	for i, item := range items {
+		list[i] = decoder.escapeItem(item)
+	}
+	return reflect.ValueOf(list), nil
+}
+
+// splitMap splits text like "k1=v1,k2=v2" to a map for unmarshaling maps.
+// The result is invalid if no item of the text contains a key-value separator.
+func (decoder *Decoder) splitMap(text string, tag reflect.StructTag) (reflect.Value, error) {
+	sep, kvsep := decoder.separator(tag), decoder.keyValueSeparator(tag)
+	if sep == "" || kvsep == "" {
+		return reflect.Value{}, nil
+	}
+	rawItems, err := splitRaw(text, sep, -1)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	data := make(map[string]interface{}, len(rawItems))
+	pairs := 0
+	for _, rawItem := range rawItems {
+		pair, err := splitRaw(rawItem, kvsep, 2)
+		if err != nil {
+			return reflect.Value{}, err
+		} else if len(pair) == 2 {
+			data[decoder.escapeItem(unquoteItem(pair[0]))] = decoder.escapeItem(unquoteItem(pair[1]))
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return reflect.Value{}, nil
+	} else if pairs != len(rawItems) {
+		return reflect.Value{}, fmt.Errorf("missing key-value separator %q in text: %s", kvsep, text)
+	}
+	return reflect.ValueOf(data), nil
+}
@@ -0,0 +1,99 @@
+package map2struct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	cases := map[string][]string{
+		"a,b,c":          {"a", "b", "c"},
+		" a , b ":        {"a", "b"},
+		`"a,b",c`:        {"a,b", "c"},
+		`a\,b,c`:         {"a,b", "c"},
+		`" a ", b`:       {" a ", "b"},
+		`a\\,b`:          {`a\`, "b"},
+		"a,,b":           {"a", "", "b"},
+		"":               nil,
+		`"say ""hi"""`:   {`say hi`},
+		`x "quoted" y,z`: {"x quoted y", "z"},
+	}
+	for text, expect := range cases {
+		items, err := splitText(text, ",", -1)
+		if err != nil {
+			t.Error("split fail:", text, err.Error())
+			return
+		} else if !reflect.DeepEqual(items, expect) {
+			t.Errorf("unexpected split result: text=%q, expect=%q, actual=%q", text, expect, items)
+			return
+		}
+	}
+	if items, err := splitText("a=b=c", "=", 2); err != nil || !reflect.DeepEqual(items, []string{"a", "b=c"}) {
+		t.Error("unexpected split result:", items, err)
+		return
+	}
+	if items, err := splitText("a::b", "::", -1); err != nil || !reflect.DeepEqual(items, []string{"a", "b"}) {
+		t.Error("unexpected split result:", items, err)
+		return
+	}
+	if _, err := splitText(`"a,b`, ",", -1); err == nil {
+		t.Error("unexpected split success")
+		return
+	}
+}
+
+func TestUnmarshalDelimitedText(t *testing.T) {
+	type TestStruct struct {
+		Slice   []int
+		Strings []string `sep:";"`
+		Array   [2]string
+		Set     map[string]bool
+		Map     map[string]int
+		Flags   map[string]bool `sep:" " kvsep:":"`
+		Bytes   []byte
+		Empty   []string
+	}
+	src := map[string]interface{}{
+		"Slice":   "1, 2, 3",
+		"Strings": `a;"b;c"`,
+		"Array":   "x,y",
+		"Set":     "a,b",
+		"Map":     `k1=1,"k=2"=2`,
+		"Flags":   "a:true b:false",
+		"Bytes":   "raw",
+		"Empty":   "",
+	}
+	a := TestStruct{Empty: []string{"x"}}
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	expect := TestStruct{
+		Slice:   []int{1, 2, 3},
+		Strings: []string{"a", "b;c"},
+		Array:   [2]string{"x", "y"},
+		Set:     map[string]bool{"a": true, "b": true},
+		Map:     map[string]int{"k1": 1, "k=2": 2},
+		Flags:   map[string]bool{"a": true, "b": false},
+		Bytes:   []byte("raw"),
+		Empty:   []string{},
+	}
+	if !reflect.DeepEqual(a, expect) {
+		t.Errorf("unexpected unmarshal result: expect=%v, actual=%v", expect, a)
+		return
+	}
+	// mixed items
+	var b map[string]string
+	if err := Unmarshal(&b, "a=1,b"); err == nil {
+		t.Error("unexpected unmarshal success:", b)
+		return
+	}
+	// disabled
+	decoder := NewDecoder()
+	decoder.Separator = ""
+	var c []string
+	if err := decoder.Unmarshal(&c, "a,b"); err == nil {
+		t.Error("unexpected unmarshal success:", c)
+		return
+	}
+}
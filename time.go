@@ -0,0 +1,201 @@
+package map2struct
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Second)
+
+	relativeTimePattern = regexp.MustCompile("^now(([+-])(.+))?$")
+	timestampPattern    = regexp.MustCompile("^-?[0-9]+(\\.[0-9]+)?$")
+
+	defaultTimeLayouts = []string{
+		"2006-01-02:15:04:05",
+		"2006-01-02:15:04:05-0700",
+		time.ANSIC,       // "Mon Jan _2 15:04:05 2006"
+		time.UnixDate,    // "Mon Jan _2 15:04:05 MST 2006"
+		time.RubyDate,    // "Mon Jan 02 15:04:05 -0700 2006"
+		time.RFC822,      // "02 Jan 06 15:04 MST"
+		time.RFC822Z,     // "02 Jan 06 15:04 -0700"
+		time.RFC850,      // "Monday, 02-Jan-06 15:04:05 MST"
+		time.RFC1123,     // "Mon, 02 Jan 2006 15:04:05 MST"
+		time.RFC1123Z,    // "Mon, 02 Jan 2006 15:04:05 -0700"
+		time.RFC3339,     // "2006-01-02T15:04:05Z07:00"
+		time.RFC3339Nano, // "2006-01-02T15:04:05.999999999Z07:00"
+		time.Kitchen,     // "3:04PM"
+		time.Stamp,       // "Jan _2 15:04:05"
+		time.StampMilli,  // "Jan _2 15:04:05.000"
+		time.StampMicro,  // "Jan _2 15:04:05.000000"
+		time.StampNano,   // "Jan _2 15:04:05.000000000"
+	}
+)
+
+func (decoder *Decoder) unmarshalTime(dest, src reflect.Value, tag reflect.StructTag) error {
+	if src.Type() == timeType {
+		dest.Set(src)
+		return nil
+	}
+	srcKind := src.Kind()
+	switch {
+	case srcKind >= reflect.Int && srcKind <= reflect.Int64:
+		return decoder.setTimestamp(dest, float64(src.Int()), src.Int(), tag)
+	case srcKind >= reflect.Uint && srcKind <= reflect.Uint64:
+		return decoder.setTimestamp(dest, float64(src.Uint()), int64(src.Uint()), tag)
+	case srcKind == reflect.Float32 || srcKind == reflect.Float64:
+		return decoder.setTimestamp(dest, src.Float(), int64(src.Float()), tag)
+	case srcKind == reflect.String:
+		timeValue, err := decoder.parseTime(src.String(), tag)
+		if err != nil {
+			return err
+		}
+		dest.Set(reflect.ValueOf(timeValue))
+		return nil
+	}
+	return badtype("time/int/float/string", src)
+}
+
+func (decoder *Decoder) parseTime(text string, tag reflect.StructTag) (time.Time, error) {
+	layout := tag.Get("layout")
+	if layout != "" && !isTimestampLayout(layout) {
+		return decoder.parseLayout(layout, text)
+	}
+	if timestampPattern.MatchString(text) {
+		unit, err := decoder.timestampUnit(tag)
+		if err != nil {
+			return time.Time{}, err
+		}
+		floatValue, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		intValue, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			intValue = int64(floatValue)
+		}
+		return decoder.timestamp(floatValue, intValue, unit), nil
+	} else if layout != "" {
+		return time.Time{}, fmt.Errorf("invalid timestamp: %s", text)
+	}
+	if match := relativeTimePattern.FindStringSubmatch(text); match != nil {
+		now := decoder.now()
+		if match[1] == "" {
+			return now, nil
+		}
+		offset, err := time.ParseDuration(match[3])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time: %s", text)
+		}
+		if match[2] == "-" {
+			offset = -offset
+		}
+		return now.Add(offset), nil
+	}
+	for _, layout := range decoder.TimeLayouts {
+		if timeValue, err := decoder.parseLayout(layout, text); err == nil {
+			return timeValue, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time layout: %s", text)
+}
+
+func (decoder *Decoder) setTimestamp(dest reflect.Value, floatValue float64, intValue int64, tag reflect.StructTag) error {
+	unit, err := decoder.timestampUnit(tag)
+	if err != nil {
+		return err
+	}
+	dest.Set(reflect.ValueOf(decoder.timestamp(floatValue, intValue, unit)))
+	return nil
+}
+
+// timestamp converts a numeric timestamp to time. The integer value is used
+// if the float value has no fractional part, for keeping the precision of
+// large nanosecond timestamps.
+func (decoder *Decoder) timestamp(floatValue float64, intValue int64, unit time.Duration) time.Time {
+	var timeValue time.Time
+	if floatValue != math.Trunc(floatValue) {
+		seconds, fraction := math.Modf(floatValue * float64(unit) / float64(time.Second))
+		timeValue = time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+	} else if unit >= time.Second {
+		timeValue = time.Unix(intValue*int64(unit/time.Second), 0)
+	} else {
+		perSecond := int64(time.Second / unit)
+		timeValue = time.Unix(intValue/perSecond, intValue%perSecond*int64(unit))
+	}
+	return timeValue.In(decoder.location())
+}
+
+func (decoder *Decoder) timestampUnit(tag reflect.StructTag) (time.Duration, error) {
+	switch layout := tag.Get("layout"); layout {
+	case "":
+		if decoder.TimestampUnit > 0 {
+			return decoder.TimestampUnit, nil
+		}
+		return time.Second, nil
+	case "unix":
+		return time.Second, nil
+	case "unixmilli":
+		return time.Millisecond, nil
+	case "unixmicro":
+		return time.Microsecond, nil
+	case "unixnano":
+		return time.Nanosecond, nil
+	default:
+		return 0, fmt.Errorf("layout %q does not accept timestamp", layout)
+	}
+}
+
+// parseLayout parses time text with the layout. Layouts without time zone are
+// parsed in TimeLocation, or in UTC like time.Parse if it is not set.
+func (decoder *Decoder) parseLayout(layout, text string) (time.Time, error) {
+	if decoder.TimeLocation == nil {
+		return time.Parse(layout, text)
+	}
+	return time.ParseInLocation(layout, text, decoder.TimeLocation)
+}
+
+func (decoder *Decoder) location() *time.Location {
+	if decoder.TimeLocation != nil {
+		return decoder.TimeLocation
+	}
+	return time.UTC
+}
+
+func (decoder *Decoder) now() time.Time {
+	if decoder.Now != nil {
+		return decoder.Now()
+	}
+	return time.Now()
+}
+
+func isTimestampLayout(layout string) bool {
+	switch layout {
+	case "unix", "unixmilli", "unixmicro", "unixnano":
+		return true
+	}
+	return false
+}
+
+func (decoder *Decoder) unmarshalDuration(dest, src reflect.Value, tag reflect.StructTag) error {
+	if src.Type() == durationType {
+		dest.Set(src)
+		return nil
+	} else if src.Kind() != reflect.String {
+		return badtype("string", src)
+	}
+	text := src.String()
+	if durationValue, found := decoder.DurationValues[text]; found {
+		dest.SetInt(int64(durationValue))
+		return nil
+	} else if durationValue, err := time.ParseDuration(text); err == nil {
+		dest.Set(reflect.ValueOf(durationValue))
+		return nil
+	}
+	return fmt.Errorf("invalid duration: %s", text)
+}
@@ -0,0 +1,188 @@
+package map2struct
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecoderTimeLayouts(t *testing.T) {
+	decoder := NewDecoder()
+	decoder.TimeLayouts = []string{"2006/01/02"}
+	var a time.Time
+	if err := decoder.Unmarshal(&a, "2017/03/04"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, time.Now().Format(time.RFC3339)); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestDecoderTimeLocation(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	decoder := NewDecoder()
+	decoder.TimeLocation = location
+	var a time.Time
+	if err := decoder.Unmarshal(&a, "2017-03-04:05:06:07"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Date(2017, 3, 4, 5, 6, 7, 0, location)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// layout with time zone
+	if err := decoder.Unmarshal(&a, "2017-03-04T05:06:07Z"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	// timestamp
+	if err := decoder.Unmarshal(&a, 0); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a.Location() != location {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+}
+
+func TestUnmarshalTimeLayoutTag(t *testing.T) {
+	type TestStruct struct {
+		Date   time.Time   `layout:"2006-01-02"`
+		Milli  time.Time   `layout:"unixmilli"`
+		Nano   time.Time   `layout:"unixnano"`
+		Dates  []time.Time `layout:"20060102"`
+		Second time.Time
+	}
+	src := map[string]interface{}{
+		"Date":   "2017-03-04",
+		"Milli":  int64(1488603966123),
+		"Nano":   "1488603966123456789",
+		"Dates":  []string{"20170304", "20170305"},
+		"Second": 1488603966.5,
+	}
+	var a TestStruct
+	if err := Unmarshal(&a, src); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	}
+	if !a.Date.Equal(time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected date:", a.Date)
+		return
+	}
+	if !a.Milli.Equal(time.Unix(1488603966, 123000000)) {
+		t.Error("unexpected unixmilli:", a.Milli)
+		return
+	}
+	if !a.Nano.Equal(time.Unix(1488603966, 123456789)) {
+		t.Error("unexpected unixnano:", a.Nano)
+		return
+	}
+	if len(a.Dates) != 2 || !a.Dates[1].Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected dates:", a.Dates)
+		return
+	}
+	if !a.Second.Equal(time.Unix(1488603966, 500000000)) {
+		t.Error("unexpected timestamp:", a.Second)
+		return
+	}
+	// layout mismatch
+	if err := Unmarshal(&a, map[string]interface{}{"Date": "2017/03/04"}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// timestamp with text layout
+	if err := Unmarshal(&a, map[string]interface{}{"Date": 1488603966}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+	// text with timestamp layout
+	if err := Unmarshal(&a, map[string]interface{}{"Milli": "now"}); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestUnmarshalRelativeTime(t *testing.T) {
+	now := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	decoder := NewDecoder()
+	decoder.Now = func() time.Time { return now }
+	var a time.Time
+	if err := decoder.Unmarshal(&a, "now"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(now) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "now-1h"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(now.Add(-time.Hour)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "now+30m"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(now.Add(30 * time.Minute)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := decoder.Unmarshal(&a, "now-1x"); err == nil {
+		t.Error("unexpected unmarshal success:", a)
+		return
+	}
+}
+
+func TestUnmarshalTimestamp(t *testing.T) {
+	var a time.Time
+	if err := Unmarshal(&a, 1488603966); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Unix(1488603966, 0)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, uint64(1488603966)); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Unix(1488603966, 0)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	if err := Unmarshal(&a, "1488603966"); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Unix(1488603966, 0)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+	decoder := NewDecoder()
+	decoder.TimestampUnit = time.Millisecond
+	if err := decoder.Unmarshal(&a, 1488603966123); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if !a.Equal(time.Unix(1488603966, 123000000)) {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+}
+
+func TestUnmarshalTimeValue(t *testing.T) {
+	now := time.Now()
+	var a time.Time
+	if err := Unmarshal(&a, now); err != nil {
+		t.Error("unmarshal fail:", err.Error())
+		return
+	} else if a != now {
+		t.Error("unexpected unmarshal result:", a)
+		return
+	}
+}